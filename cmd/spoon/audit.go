@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit {app...}",
+		Short: "Review the scripts and risky manifest fields of apps before installing them",
+		Long: strings.TrimSpace(`
+Review the scripts and risky manifest fields of apps before installing them.
+
+All scripts run during installation and uninstallation are printed with the
+manifest variables substituted. Additionally, risky patterns are flagged:
+
+  download          Scripts downloading files, bypassing hash verification
+  registry          Scripts writing to the registry
+  start-process     Scripts starting other processes
+  execution-policy  Scripts changing the execution policy
+  http              Unencrypted URLs in scripts and downloads
+  missing-hash      Downloads without a hash
+  psmodule          Apps providing a PowerShell module
+
+Installations can be blocked by listing rules in the audit policy file at
+'<scoop>/spoon/audit_policy.json', for example {"block": ["download"]}. If any
+app violates the policy, the command fails.`),
+		Example: cli.FormatUsageExample(
+			"spoon audit vscode",
+			"spoon audit go@1.21.0 --out-format json",
+		),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: autocompleteAvailable,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+			policy, err := scoop.LoadAuditPolicy(defaultScoop.AuditPolicyPath())
+			if err != nil {
+				return err
+			}
+
+			arch := scoop.ArchitectureKey(must(cmd.Flags().GetString("arch")))
+			reports := make([]*scoop.AuditReport, 0, len(args))
+			for _, arg := range args {
+				report, err := defaultScoop.AuditAvailableApp(arg, arch)
+				if err != nil {
+					return fmt.Errorf("error auditing '%s': %w", arg, err)
+				}
+				reports = append(reports, report)
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				if err := json.NewEncoder(os.Stdout).Encode(reports); err != nil {
+					return fmt.Errorf("error encoding audit reports: %w", err)
+				}
+			case "plain":
+				for _, report := range reports {
+					printAuditReport(report, policy)
+				}
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
+			if policy == nil {
+				return nil
+			}
+			var blockedApps []string
+			for _, report := range reports {
+				if len(policy.Blocked(report)) > 0 {
+					blockedApps = append(blockedApps, report.App)
+				}
+			}
+			if len(blockedApps) > 0 {
+				return fmt.Errorf("apps violating the audit policy: %s", strings.Join(blockedApps, ", "))
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringP("arch", "a", string(SystemArchitecture),
+		"use specified architecture, if app supports it")
+	cmd.RegisterFlagCompletionFunc("arch", cobra.FixedCompletions(
+		[]string{
+			string(scoop.ArchitectureKey32Bit),
+			string(scoop.ArchitectureKey64Bit),
+			string(scoop.ArchitectureKeyARM64),
+		},
+		cobra.ShellCompDirectiveDefault))
+	cmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	cmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// printAuditReport prints the scripts and findings of the report. Findings
+// blocked by the policy are marked, if a policy is passed.
+func printAuditReport(report *scoop.AuditReport, policy *scoop.AuditPolicy) {
+	headerColor := color.New(color.FgGreen, color.Bold)
+	headerColor.Printf("Audit of '%s' (%s, %s)\n", report.App, report.Version, report.Architecture)
+
+	if len(report.Scripts) == 0 {
+		fmt.Println("\nNo scripts")
+	}
+	for _, script := range report.Scripts {
+		fmt.Printf("\n%s:\n", script.Hook)
+		for _, line := range script.Lines {
+			fmt.Println("    " + line)
+		}
+	}
+	if report.PSModule != "" {
+		fmt.Printf("\nPowerShell module: %s\n", report.PSModule)
+	}
+
+	if len(report.Findings) == 0 {
+		fmt.Print("\nNo risky patterns found\n\n")
+		return
+	}
+
+	var blocked []scoop.AuditFinding
+	if policy != nil {
+		blocked = policy.Blocked(report)
+	}
+	tbl, _, _ := cli.CreateTable("Rule", "Field", "Value", "Info")
+	for _, finding := range report.Findings {
+		var info string
+		if slices.Contains(blocked, finding) {
+			info = color.RedString("Blocked")
+		}
+		tbl.AddRow(finding.Rule, finding.Field, finding.Value, info)
+	}
+
+	fmt.Print("\n")
+	tbl.Print()
+	fmt.Print("\n")
+}
@@ -69,3 +69,33 @@ func autocompleteInstalled(
 	}
 	return matches, cobra.ShellCompDirectiveDefault
 }
+
+func autocompleteShims(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	toComplete = strings.ToLower(toComplete)
+	var matches []string
+
+	defaultScoop, err := scoop.NewScoop()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	shims, err := defaultScoop.Shims()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, shim := range shims {
+		if name := shim.Name; strings.HasPrefix(strings.ToLower(name), toComplete) {
+			matches = append(matches, name)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return matches, cobra.ShellCompDirectiveDefault
+}
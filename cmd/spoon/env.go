@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+type envEntry struct {
+	Variable string `json:"variable"`
+	App      string `json:"app"`
+	Value    string `json:"value"`
+	Existed  bool   `json:"existed"`
+	Previous string `json:"previous,omitempty"`
+}
+
+func envCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env [app...]",
+		Short: "Show which environment variables have been set by which app",
+		Long: strings.TrimSpace(`
+Show which environment variables have been set by which app.
+
+For each variable, the value it had before the app has been installed is shown
+as well. This value is restored when uninstalling the app. Path entries are
+only removed on uninstallation, if they have been added by the app. Apps
+installed before spoon started recording these changes aren't shown.`),
+		Example: cli.FormatUsageExample(
+			"spoon env",
+			"spoon env openjdk17",
+			"spoon env --out-format json",
+		),
+		ValidArgsFunction: autocompleteInstalled,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+			ledger, err := defaultScoop.LoadEnvLedger()
+			if err != nil {
+				return err
+			}
+
+			entries := []envEntry{}
+			for _, appName := range slices.Sorted(maps.Keys(ledger.Apps)) {
+				if len(args) > 0 && !slices.ContainsFunc(args, func(arg string) bool {
+					return strings.EqualFold(arg, appName)
+				}) {
+					continue
+				}
+
+				record := ledger.Apps[appName]
+				for _, varRecord := range record.Vars {
+					entries = append(entries, envEntry{
+						Variable: varRecord.Key,
+						App:      appName,
+						Value:    varRecord.Value,
+						Existed:  varRecord.Existed,
+						Previous: varRecord.Previous,
+					})
+				}
+				for _, pathEntry := range record.Path {
+					entries = append(entries, envEntry{
+						Variable: "Path",
+						App:      appName,
+						Value:    pathEntry,
+					})
+				}
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+					return fmt.Errorf("error encoding environment variables: %w", err)
+				}
+			case "plain":
+				tbl, _, _ := cli.CreateTable("Variable", "App", "Value", "Previous")
+				for _, entry := range entries {
+					previous := entry.Previous
+					if !entry.Existed && entry.Variable != "Path" {
+						previous = "<unset>"
+					}
+					tbl.AddRow(entry.Variable, entry.App, entry.Value, previous)
+				}
+
+				fmt.Print("\n")
+				tbl.Print()
+				fmt.Print("\n")
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
+			return nil
+		}),
+	}
+	cmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	cmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
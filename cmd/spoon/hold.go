@@ -4,28 +4,64 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/spf13/cobra"
 )
 
 func holdCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "hold",
-		Short:              "Hold an app to disable updates",
-		Args:               cobra.MinimumNArgs(1),
-		ValidArgsFunction:  autocompleteInstalled,
-		DisableFlagParsing: true,
+		Use:   "hold",
+		Short: "Hold an app to disable updates",
+		Example: cli.FormatUsageExample(
+			"spoon hold go",
+			"spoon hold go --reason \"CI uses 1.22\" --until-version 1.23.0",
+			"spoon hold go --until 2024-12-31",
+		),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: autocompleteInstalled,
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			flags, err := getFlags(cmd, "global")
+			if must(cmd.Flags().GetBool("global")) {
+				flags, err := getFlags(cmd, "global")
+				if err != nil {
+					return fmt.Errorf("error getting flags: %w", err)
+				}
+
+				os.Exit(execScoopCommand("hold", append(flags, args...)...))
+			}
+
+			options := scoop.HoldOptions{
+				Reason:       must(cmd.Flags().GetString("reason")),
+				UntilVersion: must(cmd.Flags().GetString("until-version")),
+			}
+			if until := must(cmd.Flags().GetString("until")); until != "" {
+				date, err := scoop.ParseHoldDate(until)
+				if err != nil {
+					return fmt.Errorf("error parsing --until, expected format YYYY-MM-DD: %w", err)
+				}
+				options.Until = date
+			}
+
+			defaultScoop, err := scoop.NewScoop()
 			if err != nil {
-				return fmt.Errorf("error getting flags: %w", err)
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			for _, arg := range args {
+				if err := defaultScoop.Hold(arg, options); err != nil {
+					return fmt.Errorf("error holding '%s': %w", arg, err)
+				}
+				fmt.Printf("'%s' is now held and won't be updated anymore.\n", arg)
 			}
 
-			os.Exit(execScoopCommand("hold", append(flags, args...)...))
 			return nil
 		}),
 	}
 
 	cmd.Flags().BoolP("global", "g", false, "Hold a globally installed app")
+	cmd.Flags().StringP("reason", "r", "", "Document why the app is being held")
+	cmd.Flags().String("until", "", "Last day (YYYY-MM-DD) on which the hold is active")
+	cmd.Flags().String("until-version", "", "Version which, once available, causes the hold to be considered expired")
 
 	return cmd
 }
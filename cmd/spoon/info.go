@@ -1,27 +1,255 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/Bios-Marcel/versioncmp"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+type appInfo struct {
+	Name              string            `json:"name"`
+	Bucket            string            `json:"bucket,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Homepage          string            `json:"homepage,omitempty"`
+	License           string            `json:"license,omitempty"`
+	LatestVersion     string            `json:"latest_version,omitempty"`
+	InstalledVersion  string            `json:"installed_version,omitempty"`
+	InstalledVersions []string          `json:"installed_versions,omitempty"`
+	Architecture      string            `json:"architecture"`
+	Hold              bool              `json:"hold"`
+	Bins              []string          `json:"bins,omitempty"`
+	Shims             []string          `json:"shims,omitempty"`
+	Shortcuts         []string          `json:"shortcuts,omitempty"`
+	EnvAddPath        []string          `json:"env_add_path,omitempty"`
+	EnvSet            map[string]string `json:"env_set,omitempty"`
+	Persist           []string          `json:"persist,omitempty"`
+	Depends           []string          `json:"depends,omitempty"`
+	Notes             string            `json:"notes,omitempty"`
+}
+
 func infoCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "info {app}",
 		Short:             "Display information about a specific app",
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: autocompleteAvailable,
-		Run: func(cmd *cobra.Command, args []string) {
-			flags, err := getFlags(cmd, "verbose")
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
 			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
+				return fmt.Errorf("error getting default scoop: %w", err)
 			}
-			os.Exit(execScoopCommand("info", append(flags, args...)...))
-		},
+
+			// Installed apps are shown in the architecture they were installed
+			// in, unless explicitly requested otherwise.
+			var arch scoop.ArchitectureKey
+			if cmd.Flags().Changed("arch") {
+				arch = scoop.ArchitectureKey(must(cmd.Flags().GetString("arch")))
+			}
+			info, err := collectAppInfo(defaultScoop, args[0], arch)
+			if err != nil {
+				return err
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				if err := json.NewEncoder(os.Stdout).Encode(info); err != nil {
+					return fmt.Errorf("error encoding app info: %w", err)
+				}
+			case "plain":
+				printAppInfo(*info)
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
+			return nil
+		}),
 	}
 
+	cmd.Flags().StringP("arch", "a", string(SystemArchitecture), "Show the details for the given architecture")
+	cmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	cmd.RegisterFlagCompletionFunc("arch", cobra.FixedCompletions(
+		[]string{
+			string(scoop.ArchitectureKey32Bit),
+			string(scoop.ArchitectureKey64Bit),
+			string(scoop.ArchitectureKeyARM64),
+		},
+		cobra.ShellCompDirectiveDefault))
+	cmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
 	return cmd
 }
+
+// collectAppInfo merges the details of the available and the installed app.
+// If arch is empty, installed apps are shown in the architecture they were
+// installed in and available apps in the system architecture.
+func collectAppInfo(defaultScoop *scoop.Scoop, name string, arch scoop.ArchitectureKey) (*appInfo, error) {
+	availableApp, err := defaultScoop.FindAvailableApp(name)
+	if err != nil {
+		return nil, fmt.Errorf("error finding app: %w", err)
+	}
+	installedApp, err := defaultScoop.FindInstalledApp(name)
+	if err != nil {
+		return nil, fmt.Errorf("error finding installed app: %w", err)
+	}
+	if availableApp == nil && installedApp == nil {
+		return nil, fmt.Errorf("app '%s' doesn't exist", name)
+	}
+
+	if arch == "" {
+		arch = SystemArchitecture
+		if installedApp != nil && installedApp.Architecture != "" {
+			arch = installedApp.Architecture
+		}
+	}
+
+	info := &appInfo{Architecture: string(arch)}
+	if availableApp != nil {
+		if err := availableApp.LoadDetails(scoop.DetailFieldsAll...); err != nil {
+			return nil, fmt.Errorf("error loading app details: %w", err)
+		}
+		info.LatestVersion = availableApp.Version
+		fillAppInfo(info, availableApp.ForArch(arch))
+	}
+
+	if installedApp != nil {
+		if err := installedApp.LoadDetails(scoop.DetailFieldsAll...); err != nil {
+			return nil, fmt.Errorf("error loading installed app details: %w", err)
+		}
+
+		info.InstalledVersion = installedApp.Version
+		info.Hold = installedApp.Hold
+		info.InstalledVersions, err = defaultScoop.InstalledVersions(installedApp.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// The installed manifest is what has actually been applied to the
+		// system, so it takes precedence.
+		resolvedApp := installedApp.ForArch(arch)
+		fillAppInfo(info, resolvedApp)
+
+		// Show the values as they have been set on installation.
+		info.EnvSet = nil
+		for _, envVar := range defaultScoop.AppEnvVars(installedApp) {
+			if info.EnvSet == nil {
+				info.EnvSet = make(map[string]string)
+			}
+			info.EnvSet[envVar.Key] = envVar.Value
+		}
+
+		info.Shims = nil
+		for _, bin := range resolvedApp.Bin {
+			matches, err := filepath.Glob(filepath.Join(defaultScoop.ShimDir(), bin.ShimName()+".*"))
+			if err != nil {
+				return nil, fmt.Errorf("error looking up shims: %w", err)
+			}
+			info.Shims = append(info.Shims, matches...)
+		}
+	}
+
+	return info, nil
+}
+
+// fillAppInfo sets all manifest based fields. Calling this multiple times
+// overwrites previous values.
+func fillAppInfo(info *appInfo, app *scoop.AppResolved) {
+	info.Name = app.Name
+	if app.Bucket != nil {
+		info.Bucket = app.Bucket.Name()
+	}
+	info.Description = app.Description
+	info.Homepage = app.Homepage
+	info.License = app.License
+	info.Notes = app.Notes
+	info.EnvAddPath = app.EnvAddPath
+
+	info.Bins = nil
+	info.Shims = nil
+	for _, bin := range app.Bin {
+		info.Bins = append(info.Bins, bin.Name)
+		info.Shims = append(info.Shims, bin.ShimName())
+	}
+
+	info.Shortcuts = nil
+	for _, shortcut := range app.Shortcuts {
+		info.Shortcuts = append(info.Shortcuts, shortcut.ShortcutName)
+	}
+
+	info.EnvSet = nil
+	if len(app.EnvSet) > 0 {
+		info.EnvSet = make(map[string]string, len(app.EnvSet))
+		for _, envVar := range app.EnvSet {
+			info.EnvSet[envVar.Key] = envVar.Value
+		}
+	}
+
+	info.Persist = nil
+	for _, persist := range app.Persist {
+		info.Persist = append(info.Persist, persist.Dir)
+	}
+
+	info.Depends = nil
+	for _, dependency := range app.Depends {
+		info.Depends = append(info.Depends, dependency.Bucket+"/"+dependency.Name)
+	}
+}
+
+func printAppInfo(info appInfo) {
+	rows := [][2]string{
+		{"Name", info.Name},
+		{"Bucket", info.Bucket},
+		{"Description", info.Description},
+		{"Homepage", info.Homepage},
+		{"License", info.License},
+		{"Latest Version", info.LatestVersion},
+	}
+
+	if info.InstalledVersion != "" {
+		installedVersion := info.InstalledVersion
+		if info.LatestVersion != "" && versioncmp.Compare(
+			info.InstalledVersion, info.LatestVersion,
+			versioncmp.VersionCompareRules{},
+		) != "" {
+			installedVersion = color.YellowString(installedVersion + " (Update available)")
+		}
+		if info.Hold {
+			installedVersion += " (Held)"
+		}
+		rows = append(rows,
+			[2]string{"Installed Version", installedVersion},
+			[2]string{"Versions on Disk", strings.Join(info.InstalledVersions, ", ")},
+		)
+	} else {
+		rows = append(rows, [2]string{"Installed", "No"})
+	}
+
+	envSet := make([]string, 0, len(info.EnvSet))
+	for key, value := range info.EnvSet {
+		envSet = append(envSet, key+"="+value)
+	}
+	// Maps aren't ordered, but we want stable output.
+	slices.Sort(envSet)
+
+	rows = append(rows, [][2]string{
+		{"Architecture", info.Architecture},
+		{"Binaries", strings.Join(info.Bins, ", ")},
+		{"Shims", strings.Join(info.Shims, ", ")},
+		{"Shortcuts", strings.Join(info.Shortcuts, ", ")},
+		{"Path Additions", strings.Join(info.EnvAddPath, ", ")},
+		{"Environment", strings.Join(envSet, ", ")},
+		{"Persisted", strings.Join(info.Persist, ", ")},
+		{"Dependencies", strings.Join(info.Depends, ", ")},
+		{"Notes", info.Notes},
+	}...)
+
+	printKeyValues(rows)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/scooptest"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CollectAppInfo(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	scooptest.WriteManifest(t, defaultScoop, "app", `{
+		"version": "2.0.0",
+		"description": "Latest description",
+		"bin": "app.exe",
+		"env_set": {"APP_HOME": "$dir", "APP_DATA": "$persist_dir\\data"}
+	}`)
+
+	appDir := filepath.Join(defaultScoop.AppDir(), "app")
+	currentDir := filepath.Join(appDir, "current")
+	require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(appDir, "1.0.0"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"), []byte(`{
+		"version": "1.0.0",
+		"description": "Installed description",
+		"env_set": {"APP_HOME": "$dir", "APP_DATA": "$persist_dir\\data"}
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+		[]byte(`{"bucket": "main", "architecture": "32bit", "hold": true}`), 0o600))
+
+	t.Run("installed", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := collectAppInfo(defaultScoop, "app", "")
+		require.NoError(t, err)
+		require.Equal(t, &appInfo{
+			Name:              "app",
+			Bucket:            "main",
+			Description:       "Installed description",
+			LatestVersion:     "2.0.0",
+			InstalledVersion:  "1.0.0",
+			InstalledVersions: []string{"1.0.0"},
+			Architecture:      string(scoop.ArchitectureKey32Bit),
+			Hold:              true,
+			// Values are shown as set on installation.
+			EnvSet: map[string]string{
+				"APP_HOME": currentDir,
+				"APP_DATA": defaultScoop.AppPersistDir("app") + `\data`,
+			},
+		}, info)
+	})
+	t.Run("explicit architecture", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := collectAppInfo(defaultScoop, "app", scoop.ArchitectureKey64Bit)
+		require.NoError(t, err)
+		require.Equal(t, string(scoop.ArchitectureKey64Bit), info.Architecture)
+	})
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := collectAppInfo(defaultScoop, "missing", "")
+		require.Error(t, err)
+	})
+}
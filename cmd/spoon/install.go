@@ -3,7 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/Bios-Marcel/spoon/internal/windows"
 	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/spf13/cobra"
 )
@@ -17,6 +20,23 @@ func installCmd() *cobra.Command {
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
 			// Flags we currently do not support
 			if must(cmd.Flags().GetBool("global")) || !must(cmd.Flags().GetBool("experimental")) {
+				for _, flag := range []string{"dry-run", "no-profile", "audit"} {
+					if cmd.Flags().Changed(flag) {
+						return fmt.Errorf("--%s is only supported in combination with --experimental", flag)
+					}
+				}
+
+				// scoop doesn't know about our audit policy, so we enforce it
+				// before handing off. Dependencies aren't checked though.
+				defaultScoop, err := scoop.NewScoop()
+				if err != nil {
+					return fmt.Errorf("error retrieving scoop instance: %w", err)
+				}
+				if err := checkAuditPolicy(defaultScoop, args,
+					scoop.ArchitectureKey(must(cmd.Flags().GetString("arch")))); err != nil {
+					return err
+				}
+
 				flags, err := getFlags(cmd, "global", "independent", "no-cache",
 					"no-update-scoop", "skip", "arch")
 				if err != nil {
@@ -31,6 +51,33 @@ func installCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("error retrieving scoop instance: %w", err)
 			}
+			defaultScoop.ScriptRunner = &scoop.StreamingScriptRunner{
+				PowerShell: scoop.PowerShellRunner{
+					NoProfile: must(cmd.Flags().GetBool("no-profile")),
+				},
+				Sink: printScriptEvent,
+			}
+
+			defaultScoop.AuditPolicy, err = scoop.LoadAuditPolicy(defaultScoop.AuditPolicyPath())
+			if err != nil {
+				return err
+			}
+			if must(cmd.Flags().GetBool("audit")) {
+				for _, arg := range args {
+					report, err := defaultScoop.AuditAvailableApp(arg, scoop.ArchitectureKey(arch))
+					if err != nil {
+						return fmt.Errorf("error auditing '%s': %w", arg, err)
+					}
+					printAuditReport(report, defaultScoop.AuditPolicy)
+				}
+				if !askYesNo("Continue with the installation?") {
+					return nil
+				}
+			}
+
+			if must(cmd.Flags().GetBool("dry-run")) {
+				return dryRunInstall(defaultScoop, args, scoop.ArchitectureKey(arch))
+			}
 
 			installErrors := defaultScoop.InstallAll(args, scoop.ArchitectureKey(arch))
 			for _, err := range installErrors {
@@ -49,6 +96,9 @@ func installCmd() *cobra.Command {
 	cmd.Flags().BoolP("no-cache", "k", false, "Don't use download cache")
 	cmd.Flags().BoolP("no-update-scoop", "u", false, "Don't use scoop before i if it's outdated")
 	cmd.Flags().BoolP("skip", "s", false, "Skip hash validation")
+	cmd.Flags().Bool("no-profile", false, "Don't load the PowerShell profile when running manifest scripts")
+	cmd.Flags().Bool("dry-run", false, "Install into a temporary directory without running any scripts or installers and print what would have been run")
+	cmd.Flags().Bool("audit", false, "Print the scripts and risky fields of the apps and ask for confirmation before installing")
 	// We default to our system architecture here. If scoop encounters an
 	// unsupported arch, it is ignored. We'll do the same.
 	cmd.Flags().StringP("arch", "a", string(SystemArchitecture),
@@ -63,3 +113,115 @@ func installCmd() *cobra.Command {
 
 	return cmd
 }
+
+// checkAuditPolicy returns an [*scoop.AuditBlockedError] for the first app
+// violating the audit policy. Without a policy, nothing is checked.
+func checkAuditPolicy(defaultScoop *scoop.Scoop, apps []string, arch scoop.ArchitectureKey) error {
+	policy, err := scoop.LoadAuditPolicy(defaultScoop.AuditPolicyPath())
+	if err != nil || policy == nil {
+		return err
+	}
+
+	for _, app := range apps {
+		report, err := defaultScoop.AuditAvailableApp(app, arch)
+		if err != nil {
+			return fmt.Errorf("error auditing '%s': %w", app, err)
+		}
+		if blocked := policy.Blocked(report); len(blocked) > 0 {
+			return &scoop.AuditBlockedError{App: report.App, Findings: blocked}
+		}
+	}
+	return nil
+}
+
+// printScriptEvent prints the output of manifest scripts indented, so it can
+// be told apart from the output of spoon.
+func printScriptEvent(event scoop.ScriptEvent) {
+	switch event := event.(type) {
+	case *scoop.ScriptStarted:
+		fmt.Printf("Running %s of '%s' ...\n", event.Script.Hook, event.Script.App)
+	case *scoop.ScriptOutput:
+		if event.Stderr {
+			fmt.Fprintln(os.Stderr, "    "+event.Line)
+		} else {
+			fmt.Println("    " + event.Line)
+		}
+	}
+}
+
+// dryRunInstall installs the apps into a temporary isolated scoop, recording
+// all scripts and installers instead of running them. Downloads are cached as
+// usual. Afterwards, the recorded scripts and installers are printed.
+func dryRunInstall(defaultScoop *scoop.Scoop, apps []string, arch scoop.ArchitectureKey) error {
+	root, err := os.MkdirTemp("", "spoon-dry-run-")
+	if err != nil {
+		return fmt.Errorf("error creating temporary scoop dir: %w", err)
+	}
+	defer func() {
+		if err := windows.ForceRemoveAll(root); err != nil {
+			fmt.Printf("error deleting temporary scoop dir '%s': %s\n", root, err)
+		}
+	}()
+
+	scripts, processes, installErrors, err := recordInstall(defaultScoop, root, apps, arch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("\n")
+	if len(scripts) == 0 && len(processes) == 0 {
+		fmt.Println("No scripts or installers would be run")
+	}
+	for _, script := range scripts {
+		fmt.Printf("%s of '%s':\n", script.Hook, script.App)
+		for _, line := range script.Lines {
+			fmt.Println("    " + line)
+		}
+	}
+	for _, process := range processes {
+		fmt.Println("Installer:")
+		fmt.Println("    " + strings.Join(append([]string{filepath.Base(process.Executable)}, process.Args...), " "))
+	}
+
+	for _, err := range installErrors {
+		fmt.Println(err)
+	}
+	if len(installErrors) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// recordInstall installs the apps into an isolated scoop at root, which
+// shares buckets and cache with the default scoop. Scripts and installers
+// are recorded instead of being run.
+func recordInstall(
+	defaultScoop *scoop.Scoop,
+	root string,
+	apps []string,
+	arch scoop.ArchitectureKey,
+) ([]*scoop.Script, []scoop.RecordedProcess, []error, error) {
+	scripts := &scoop.RecordingScriptRunner{}
+	processes := &scoop.RecordingProcessRunner{}
+
+	dryRunScoop := scoop.NewCustomScoop(root)
+	dryRunScoop.Isolated = true
+	dryRunScoop.EnvStore = scoop.NewMemoryEnvStore(nil)
+	dryRunScoop.ScriptRunner = scripts
+	dryRunScoop.ProcessRunner = processes
+	dryRunScoop.AuditPolicy = defaultScoop.AuditPolicy
+	for _, dirs := range [][2]string{
+		{defaultScoop.CacheDir(), dryRunScoop.CacheDir()},
+		{defaultScoop.BucketDir(), dryRunScoop.BucketDir()},
+	} {
+		if err := os.MkdirAll(dirs[0], os.ModePerm); err != nil {
+			return nil, nil, nil, fmt.Errorf("error creating shared dir: %w", err)
+		}
+		if err := dryRunScoop.Linker.LinkDir(dirs[0], dirs[1]); err != nil {
+			return nil, nil, nil, fmt.Errorf("error linking shared dir: %w", err)
+		}
+	}
+
+	installErrors := dryRunScoop.InstallAll(apps, arch)
+	return scripts.Scripts(), processes.Processes(), installErrors, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/scooptest"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecordInstall(t *testing.T) {
+	t.Parallel()
+
+	archive := scooptest.ServeZip(t, map[string]string{"setup.exe": "binary"})
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	scooptest.WriteManifest(t, defaultScoop, "app", fmt.Sprintf(`{
+		"version": "1.0.0",
+		"url": "%s/app.zip",
+		"hash": "%s",
+		"pre_install": "Write-Host 'pre'",
+		"installer": {"file": "setup.exe", "args": ["/S"]},
+		"env_set": {"APP_HOME": "$dir"}
+	}`, archive.URL, archive.Hash))
+
+	root := t.TempDir()
+	scripts, processes, installErrors, err := recordInstall(defaultScoop, root, []string{"app"}, scoop.ArchitectureKey64Bit)
+	require.NoError(t, err)
+	require.Empty(t, installErrors)
+
+	require.Len(t, scripts, 1)
+	require.Equal(t, scoop.ScriptHookPreInstall, scripts[0].Hook)
+	require.Equal(t, []string{"Write-Host 'pre'"}, scripts[0].Lines)
+	require.Len(t, processes, 1)
+	require.Equal(t, "setup.exe", filepath.Base(processes[0].Executable))
+	require.Equal(t, []string{"/S"}, processes[0].Args)
+
+	// Only the temporary scoop is touched, apart from the shared cache.
+	require.NoDirExists(t, defaultScoop.AppDir())
+	require.FileExists(t, filepath.Join(root, "apps", "app", "current", "manifest.json"))
+}
+
+func Test_CheckAuditPolicy(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	scooptest.WriteManifest(t, defaultScoop, "app", `{"version": "1.0.0", "url": "http://example.com/app.zip"}`)
+
+	// Without a policy, nothing is blocked.
+	require.NoError(t, checkAuditPolicy(defaultScoop, []string{"app"}, scoop.ArchitectureKey64Bit))
+
+	require.NoError(t, os.MkdirAll(defaultScoop.SpoonDir(), os.ModePerm))
+	require.NoError(t, os.WriteFile(defaultScoop.AuditPolicyPath(),
+		[]byte(`{"block": ["missing-hash"]}`), 0o600))
+
+	err := checkAuditPolicy(defaultScoop, []string{"main/app"}, scoop.ArchitectureKey64Bit)
+	var blockedErr *scoop.AuditBlockedError
+	require.ErrorAs(t, err, &blockedErr)
+	require.Equal(t, "app", blockedErr.App)
+	require.Equal(t, "hash", blockedErr.Findings[0].Field)
+}
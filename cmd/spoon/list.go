@@ -1,21 +1,232 @@
 package main
 
 import (
+	"cmp"
+	"encoding/json"
+	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/spf13/cobra"
 )
 
+type listEntry struct {
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Bucket       string    `json:"bucket"`
+	Architecture string    `json:"architecture"`
+	Hold         bool      `json:"hold"`
+	HoldReason   string    `json:"hold_reason,omitempty"`
+	Outdated     bool      `json:"outdated,omitempty"`
+	InstallDate  time.Time `json:"install_date"`
+	Size         int64     `json:"size"`
+}
+
+const (
+	SortFieldUpdated = "updated"
+	SortFieldSize    = "size"
+)
+
 func listCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "list",
+		Use:   "list [pattern]",
 		Short: "List installed apps",
-		Args:  cobra.MaximumNArgs(1),
+		Long:  "List installed apps. The optional pattern is a case insensitive regular expression matched against the app name.",
+		Example: cli.FormatUsageExample(
+			"spoon list",
+			"spoon list ^go",
+			"spoon list --bucket extras --sort size",
+			"spoon list --held --out-format json",
+		),
+		Args: cobra.MaximumNArgs(1),
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			os.Exit(execScoopCommand("list", args...))
+			var pattern *regexp.Regexp
+			if len(args) > 0 {
+				var err error
+				pattern, err = regexp.Compile("(?i)" + args[0])
+				if err != nil {
+					return fmt.Errorf("error parsing pattern: %w", err)
+				}
+			}
+
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			apps, err := defaultScoop.InstalledApps()
+			if err != nil {
+				return fmt.Errorf("error getting installed apps: %w", err)
+			}
+
+			bucketFilter := must(cmd.Flags().GetString("bucket"))
+			onlyHeld := must(cmd.Flags().GetBool("held"))
+			onlyOutdated := must(cmd.Flags().GetBool("outdated"))
+
+			// Finding outdated apps requires reading the manifests of all
+			// buckets, so we only do it on request.
+			var outdated map[string]bool
+			if onlyOutdated {
+				outdatedApps, err := defaultScoop.GetOutdatedApps()
+				if err != nil {
+					return fmt.Errorf("error determining outdated apps: %w", err)
+				}
+				outdated = make(map[string]bool, len(outdatedApps))
+				for _, app := range outdatedApps {
+					outdated[app.Name] = true
+				}
+			}
+
+			entries := make([]listEntry, 0, len(apps))
+			for _, app := range apps {
+				if pattern != nil && !pattern.MatchString(app.Name) {
+					continue
+				}
+				var bucket string
+				if app.Bucket != nil {
+					bucket = app.Bucket.Name()
+				}
+				if bucketFilter != "" && !strings.EqualFold(bucketFilter, bucket) {
+					continue
+				}
+				if onlyHeld && !app.Hold {
+					continue
+				}
+				if onlyOutdated && !outdated[app.Name] {
+					continue
+				}
+
+				if err := app.LoadDetails(scoop.DetailFieldVersion); err != nil {
+					return fmt.Errorf("error loading details of '%s': %w", app.Name, err)
+				}
+
+				entries = append(entries, listEntry{
+					Name:         app.Name,
+					Version:      app.Version,
+					Bucket:       bucket,
+					Architecture: string(app.Architecture),
+					Hold:         app.Hold,
+					HoldReason:   app.HoldOptions.Reason,
+					Outdated:     outdated[app.Name],
+					InstallDate:  app.InstallDate,
+				})
+			}
+
+			// Calculating the size requires walking the whole installation,
+			// so we parallelise it.
+			var sizeWaitGroup sync.WaitGroup
+			sizeErrors := make([]error, len(entries))
+			for index := range entries {
+				sizeWaitGroup.Add(1)
+				go func() {
+					defer sizeWaitGroup.Done()
+					entries[index].Size, sizeErrors[index] = defaultScoop.AppSize(entries[index].Name)
+				}()
+			}
+			sizeWaitGroup.Wait()
+			for index, err := range sizeErrors {
+				if err != nil {
+					return fmt.Errorf("error determining size of '%s': %w", entries[index].Name, err)
+				}
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				// Same as search, JSON is only sorted if explicitly requested.
+				if cmd.Flags().Changed("sort") {
+					sortListEntries(entries, must(cmd.Flags().GetStringSlice("sort")))
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+					return fmt.Errorf("error encoding apps: %w", err)
+				}
+			case "plain":
+				sortListEntries(entries, must(cmd.Flags().GetStringSlice("sort")))
+
+				tbl, _, _ := cli.CreateTable("Name", "Version", "Bucket", "Arch", "Updated", "Size", "Info")
+				for _, entry := range entries {
+					var info []string
+					if entry.Hold {
+						if entry.HoldReason != "" {
+							info = append(info, fmt.Sprintf("Held package (%s)", entry.HoldReason))
+						} else {
+							info = append(info, "Held package")
+						}
+					}
+					if entry.Outdated {
+						info = append(info, "Outdated")
+					}
+					tbl.AddRow(
+						entry.Name,
+						entry.Version,
+						entry.Bucket,
+						entry.Architecture,
+						entry.InstallDate.Format(time.DateTime),
+						formatSize(entry.Size),
+						strings.Join(info, ","),
+					)
+				}
+
+				fmt.Print("\n")
+				tbl.Print()
+				fmt.Print("\n")
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
 			return nil
 		}),
 	}
 
+	cmd.Flags().StringP("bucket", "b", "", "Only list apps installed from the given bucket")
+	cmd.Flags().Bool("held", false, "Only list held apps")
+	cmd.Flags().Bool("outdated", false, "Only list apps with available updates")
+	cmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	cmd.Flags().StringSliceP("sort", "s", []string{SortFieldName}, "Specifies fields which are sorted by. Available: name, bucket, updated, size; The order determines the sorting weight. For JSON format, sorting is disabled by default.")
+	cmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(
+		[]string{SortFieldName, SortFieldBucket, SortFieldUpdated, SortFieldSize},
+		cobra.ShellCompDirectiveNoFileComp))
+
 	return cmd
 }
+
+// sortListEntries sorts the same way as search does, but additionally allows
+// sorting by installation date and size, both descending.
+func sortListEntries(entries []listEntry, sortFields []string) {
+	sortByFields(entries, sortFields, map[string]func(a, b listEntry) int{
+		SortFieldName: func(a, b listEntry) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		},
+		SortFieldBucket: func(a, b listEntry) int {
+			return strings.Compare(strings.ToLower(a.Bucket), strings.ToLower(b.Bucket))
+		},
+		SortFieldUpdated: func(a, b listEntry) int {
+			return b.InstallDate.Compare(a.InstallDate)
+		},
+		SortFieldSize: func(a, b listEntry) int {
+			return cmp.Compare(b.Size, a.Size)
+		},
+	})
+}
+
+// formatSize formats the given amount of bytes in a human readable way, using
+// binary units.
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	divisor, exponent := int64(unit), 0
+	for remainder := bytes / unit; remainder >= unit; remainder /= unit {
+		divisor *= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(divisor), "KMGTPE"[exponent])
+}
@@ -52,6 +52,10 @@ func main() {
 	rootCmd.AddCommand(statusCmd())
 	rootCmd.AddCommand(infoCmd())
 	rootCmd.AddCommand(dependsCmd())
+	rootCmd.AddCommand(whichCmd())
+	rootCmd.AddCommand(shimCmd())
+	rootCmd.AddCommand(envCmd())
+	rootCmd.AddCommand(auditCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		if strings.HasPrefix(err.Error(), "unknown command") {
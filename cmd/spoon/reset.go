@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"slices"
 
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/spf13/cobra"
 )
 
@@ -11,15 +13,51 @@ func resetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "reset",
 		Short:             "Reset an app to resolve conflicts",
+		Long:              "Re-creates shims, shortcuts, environment variables and persist links of installed apps. This is useful if two apps provide the same shim.",
 		Args:              cobra.ArbitraryArgs,
 		ValidArgsFunction: autocompleteInstalled,
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			flags, err := getFlags(cmd, "all")
+			all := must(cmd.Flags().GetBool("all")) || slices.Contains(args, "*")
+			if !all && len(args) == 0 {
+				return errors.New("either pass apps to reset or use --all")
+			}
+
+			defaultScoop, err := scoop.NewScoop()
 			if err != nil {
-				return fmt.Errorf("error getting flags: %w", err)
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			var apps []*scoop.InstalledApp
+			if all {
+				apps, err = defaultScoop.InstalledApps()
+				if err != nil {
+					return fmt.Errorf("error getting installed apps: %w", err)
+				}
+			} else {
+				for _, arg := range args {
+					app, err := defaultScoop.FindInstalledApp(arg)
+					if err != nil {
+						return fmt.Errorf("error finding app '%s': %w", arg, err)
+					}
+					if app == nil {
+						return fmt.Errorf("app '%s' is not installed", arg)
+					}
+					apps = append(apps, app)
+				}
 			}
 
-			os.Exit(execScoopCommand("reset", append(flags, args...)...))
+			var failed bool
+			for _, app := range apps {
+				fmt.Printf("Resetting '%s' ...\n", app.Name)
+				if err := defaultScoop.Reset(app); err != nil {
+					fmt.Printf("Error resetting '%s': %s\n", app.Name, err)
+					failed = true
+				}
+			}
+
+			if failed {
+				return errors.New("not all apps could be reset")
+			}
 			return nil
 		}),
 	}
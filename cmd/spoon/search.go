@@ -300,30 +300,14 @@ func must[T any](value T, err error) T {
 // order. The earier in the array the field, the higher the weight during
 // sorting. The search is case insensitive.
 func sort(matches []match, sortFields []string) {
-	var compareFns []func(a, b match) int
-	for _, sortField := range sortFields {
-		switch sortField {
-		case SortFieldName:
-			compareFns = append(compareFns, func(a, b match) int {
-				return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
-			})
-		case SortFieldBucket:
-			compareFns = append(compareFns, func(a, b match) int {
-				return strings.Compare(strings.ToLower(a.Bucket), strings.ToLower(b.Bucket))
-			})
-		}
-	}
-
-	if len(compareFns) > 0 {
-		slices.SortStableFunc(matches, func(a, b match) int {
-			for _, compareFn := range compareFns {
-				if result := compareFn(a, b); result != 0 {
-					return result
-				}
-			}
-			return 0
-		})
-	}
+	sortByFields(matches, sortFields, map[string]func(a, b match) int{
+		SortFieldName: func(a, b match) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		},
+		SortFieldBucket: func(a, b match) int {
+			return strings.Compare(strings.ToLower(a.Bucket), strings.ToLower(b.Bucket))
+		},
+	})
 }
 
 func newMatch(app *scoop.App, bucket string) match {
@@ -3,10 +3,10 @@ package main
 import (
 	"errors"
 	"fmt"
-	"io/fs"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/Bios-Marcel/spoon/internal/windows"
@@ -14,28 +14,28 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func restoreEnvVars(vars []scoop.EnvVar) {
-	var envResetErr error
-	for _, envVar := range vars {
-		// We attempt to reset everything as well as we can, even if one
-		// or more calls fail.
-		if err := windows.SetPersistentEnvValue(envVar.Key, envVar.Value); err != nil {
-			envResetErr = err
-		}
-	}
-
-	// Even if we couldn't reset everything / anything here, we can't do
-	// anything against it. So we'll keep going.
-	if envResetErr != nil {
-		fmt.Println("Note that we weren't able to restore all persistent environment variables properly")
-	}
-}
-
 func shellCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "shell",
 		Short: "Manage nix-shell like scoop environments",
+		Long: strings.TrimSpace(`
+Manage nix-shell like scoop environments.
+
+Without a subcommand, the environment defined by the spoon.json in the current
+directory is set up. A spoon.json looks like this:
+
+{
+    "apps": ["main/go@1.22.1", "golangci-lint"],
+    "env": {"GOFLAGS": "-mod=vendor"}
+}`),
+		Args: cobra.NoArgs,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			return setupProjectShell(must(cmd.Flags().GetString("shell")))
+		}),
 	}
+	cmd.Flags().String("shell", "", "Shell to generate the activation script for; Defaults to the current shell")
+	cmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(
+		activationShellNames(), cobra.ShellCompDirectiveNoFileComp))
 	cmd.AddCommand(&cobra.Command{
 		// This command is quite handy, as a simple rm on the console will fail
 		// and people will have to open their explorer.
@@ -43,246 +43,283 @@ func shellCmd() *cobra.Command {
 		Short: "Delete all scoop environment related files",
 		Args:  cobra.NoArgs,
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			// Delete shellscript first, as nothing can go wrong.
-			if err := os.RemoveAll("shell.ps1"); err != nil {
-				return fmt.Errorf("error deleting '%s': %w", "shell.ps1", err)
+			root, err := filepath.Abs(".scoop")
+			if err != nil {
+				return fmt.Errorf("error getting abs scoop path: %w", err)
 			}
-
-			// We can't delete symlinks that are read-only, therefore we need to manually
-			// set write-permissions.
-			if err := filepath.WalkDir(".scoop", func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
-
-				if d.Type()&os.ModeSymlink == os.ModeSymlink {
-					if err := os.Chmod(path, 0o600); err != nil {
-						return fmt.Errorf("error setting symlink permissions: %w", err)
-					}
-				}
-
-				return nil
-			}); err != nil {
-				if os.IsNotExist(err) {
-					return nil
-				}
+			if err := deleteShellEnvironment(root); err != nil {
 				return err
 			}
-			if err := os.RemoveAll(".scoop"); err != nil {
-				return fmt.Errorf("error deleting '%s': %w", `.scoop`, err)
+
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
 			}
-			return nil
+			return unregisterShell(defaultScoop, root)
 		}),
 	})
-	cmd.AddCommand(&cobra.Command{
-		Use:               "setup",
-		Short:             "Create a subshell with the given applications on your PATH",
-		Args:              cobra.MinimumNArgs(1),
+	setupCmd := &cobra.Command{
+		Use:   "setup [app...]",
+		Short: "Create a subshell with the given applications on your PATH",
+		Long: strings.TrimSpace(`
+Create a subshell with the given applications on your PATH.
+
+If no apps are passed, the apps and environment variables defined in the
+spoon.json in the current directory are used. Running setup again only installs
+and uninstalls apps that have changed.`),
 		ValidArgsFunction: autocompleteAvailable,
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
-				return nil
+			if len(args) > 0 {
+				return setupShell(must(cmd.Flags().GetString("shell")), args, nil, "")
 			}
+			return setupProjectShell(must(cmd.Flags().GetString("shell")))
+		}),
+	}
+	setupCmd.Flags().String("shell", "", "Shell to generate the activation script for; Defaults to the current shell")
+	setupCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(
+		activationShellNames(), cobra.ShellCompDirectiveNoFileComp))
+	cmd.AddCommand(setupCmd)
+	cmd.AddCommand(shellRunCmd())
+	cmd.AddCommand(shellListCmd())
+	cmd.AddCommand(shellGCCmd())
+	cmd.AddCommand(shellRecoverCmd())
 
-			defaultScoop, err := scoop.NewScoop()
-			if err != nil {
-				return fmt.Errorf("error finding defautl scoop: %w", err)
-			}
+	return cmd
+}
 
-			// If we are using PowershellCore, we can't user PowershellDesktop
-			// and vice versa, as the module paths will cause conflicts, causing
-			// us to not find `Get-FileHash` for example.
-			shell, err := windows.GetShellExecutable()
-			if err != nil {
-				return fmt.Errorf("error determining shell: %w", err)
-			}
+// setupShell installs the given apps into the shell scoop in the current
+// directory and generates the activation script. If projectHash is passed and
+// matches the hash of the previous setup, the installation is skipped.
+func setupShell(shell string, apps []string, extraEnv map[string]string, projectHash string) error {
+	// The generated script starts a subshell of the same type the user is
+	// currently using, unless requested otherwise.
+	if shell == "" {
+		var err error
+		shell, err = windows.GetShellExecutable()
+		if err != nil {
+			return fmt.Errorf("error determining shell: %w", err)
+		}
+	}
+	script := activationScriptFor(shell)
 
-			shell = strings.ToLower(shell)
-			switch shell {
-			case "pwsh.exe", "powershell.exe":
-				break
-			default:
-				// If the user runs something else, such as nushell or cmd, we
-				// will fallback to default powershell for now.
-				shell = "powershell.exe"
-			}
+	tempScoopPath, err := filepath.Abs("./.scoop")
+	if err != nil {
+		return fmt.Errorf("error getting abs scoop path: %w", err)
+	}
+	tempScoop, err := prepareShellScoop(tempScoopPath, apps, projectHash)
+	if err != nil {
+		return err
+	}
 
-			oldUserEnv, err := windows.GetPersistentEnvValues()
-			if err != nil {
-				return fmt.Errorf("error backing up user enviroment: %w", err)
-			}
+	pathAdditions, tempEnv, err := shellEnvironment(tempScoop, apps, extraEnv)
+	if err != nil {
+		return err
+	}
 
-			// Windows has User-Level and System-Level PATHs. When calling
-			// os.GetEnv, you always get the combined PATH. Since scoop
-			// manipulates the User-Level PATH during install, we need to
-			// restore it to its old User-Level PATH, instead of the old
-			// combined PATH, as we pollute the path otherwise.
-			oldUserPath := oldUserEnv["Path"]
-			if oldUserPath == "" {
-				return errors.New("user-level persistent path empty, please report a bug")
-			}
-			envToRestore := []scoop.EnvVar{{Key: "Path", Value: oldUserPath}}
+	if err := os.WriteFile(
+		script.FileName,
+		[]byte(script.Generate(pathAdditions, tempEnv)),
+		0o700,
+	); err != nil {
+		return fmt.Errorf("error creating shell script: %w", err)
+	}
+	fmt.Printf("Run '%s' to enter the environment\n", script.Usage)
+	return nil
+}
 
-			tempScoopPath, err := filepath.Abs("./.scoop")
-			if err != nil {
-				return fmt.Errorf("error getting abs scoop path: %w", err)
-			}
-			tempScoop := scoop.NewCustomScoop(tempScoopPath)
-
-			// down the line, so we trim space.
-			newShimPath := filepath.Join(tempScoopPath, "shims")
-			newTempPath := newShimPath
-
-			installEnv := os.Environ()
-
-			var scoopPathSet bool
-			// We want to keep the env in tact for subprocesses, as setting
-			// cmd.Env will actually overwrite the whole env.
-			for index, envVar := range installEnv {
-				keyVal := strings.SplitN(envVar, "=", 2)
-				if len(keyVal) < 2 {
-					continue
-				}
-
-				// Sometimes its `PATH` and sometimes its `Path`.
-				key := keyVal[0]
-				if strings.EqualFold(key, "PATH") {
-					installEnv[index] = key + "=" + newShimPath + ";" + keyVal[1]
-					continue
-				} else if strings.EqualFold(key, "SCOOP") {
-					installEnv[index] = key + "=" + tempScoopPath
-					// SCOOP var might not be present, so we Your local changes
-					// to the following files would be overwritten by mergeneed
-					// to append it if it wasn't overwritten already.
-					scoopPathSet = true
-				}
-			}
-			if !scoopPathSet {
-				installEnv = append(installEnv, "SCOOP="+tempScoopPath)
-			}
+// prepareShellScoop creates a scoop at the given root and installs the given
+// apps into it. If projectHash is passed and matches the hash of the previous
+// setup, the installation is skipped.
+func prepareShellScoop(root string, apps []string, projectHash string) (*scoop.Scoop, error) {
+	defaultScoop, err := scoop.NewScoop()
+	if err != nil {
+		return nil, fmt.Errorf("error finding defautl scoop: %w", err)
+	}
+	if err := registerShell(defaultScoop, root, apps); err != nil {
+		return nil, err
+	}
 
-			/*
-				TODO:
+	tempScoop := scoop.NewCustomScoop(root)
+	// Everything required is set up by the shell script, so there's no need
+	// to touch the user environment.
+	tempScoop.Isolated = true
+	tempScoop.EnvStore = scoop.NewMemoryEnvStore(nil)
+	// Apps the user already has installed don't need to be extracted again.
+	tempScoop.SharedAppDir = defaultScoop.AppDir()
+	// The audit policy of the user applies to shell environments as well.
+	tempScoop.AuditPolicy, err = scoop.LoadAuditPolicy(defaultScoop.AuditPolicyPath())
+	if err != nil {
+		return nil, err
+	}
 
-				Support for different shells (pwsh / powershell / batch / bash / wsl?)
-				Proper support for subshelling (this didnt work due to buggy scoop shimming, nothing actually stops us from doing this.)
-				$env:CUSTOM in env_set
-				$source variable
-				Prevent install (unpack) if app is already installed in user, instead hardlink (its basically free)
-			*/
+	/*
+		TODO:
 
-			if err := os.MkdirAll("./.scoop/apps/scoop", os.ModeDir); err != nil {
-				return fmt.Errorf("error creating temporary scoop dir: %w", err)
-			}
+		Proper support for subshelling (this didnt work due to buggy scoop shimming, nothing actually stops us from doing this.)
+		$source variable
+	*/
 
-			if err := windows.CreateJunctions([][2]string{
-				{defaultScoop.CacheDir(), tempScoop.CacheDir()},
-				{defaultScoop.ScoopInstallationDir(), tempScoop.ScoopInstallationDir()},
-				{defaultScoop.BucketDir(), tempScoop.BucketDir()},
-			}...); err != nil {
-				return fmt.Errorf("error creating junctions: %w", err)
-			}
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating temporary scoop dir: %w", err)
+	}
 
-			var tempEnv []scoop.EnvVar
-			// Scoop has a bug, where running something such as
-			// `scoop install lua golangci-lint@v1.56.2` causes an issue. It
-			// seems scoop doesn't parse the arguments properly. Therefore we
-			// need to execute the command multiple times.
-			for _, dependency := range args {
-				scoopInstallCmd := exec.Command(
-					shell,
-					"-NoProfile",
-					"-Command",
-					"scoop",
-					"install",
-					dependency,
-				)
-				scoopInstallCmd.Env = installEnv
-				scoopInstallCmd.Stdout = os.Stdout
-				scoopInstallCmd.Stderr = os.Stderr
-				scoopInstallCmd.Stdin = os.Stdin
-
-				if err = scoopInstallCmd.Run(); err != nil {
-					break
-				}
-
-				// After successful installation, we need to properly setup the
-				// environment for each apps. Some apps require extra
-				// environment variables and some apps use env_add_path instead
-				// of specifying shims.
-				var app *scoop.InstalledApp
-				app, err = tempScoop.FindInstalledApp(dependency)
-				if err != nil {
-					break
-				}
-				if err = app.LoadDetails(
-					scoop.DetailFieldEnvSet,
-					scoop.DetailFieldEnvAddPath,
-				); err != nil {
-					break
-				}
-
-				dir := filepath.Join(tempScoopPath, "apps", app.Name, "current")
-				for _, pathEntry := range app.EnvAddPath {
-					if !filepath.IsAbs(pathEntry) {
-						pathEntry = filepath.Join(dir, pathEntry)
-					}
-					newTempPath = pathEntry + ";" + newTempPath
-				}
-
-				// scoop supports some variables in certain fields. Sadly these
-				// fields don't document their supported variables, but I found
-				// these two in my local buckets.
-				persistDir := filepath.Join(tempScoopPath, "persist", app.Name)
-				for _, envVar := range app.EnvSet {
-					envToRestore = append(envToRestore, scoop.EnvVar{
-						Key:   envVar.Key,
-						Value: oldUserEnv[envVar.Key],
-					})
-
-					envVar.Value = strings.ReplaceAll(envVar.Value, "$persist_dir", persistDir)
-					envVar.Value = strings.ReplaceAll(envVar.Value, "$dir", dir)
-					tempEnv = append(tempEnv, envVar)
-				}
-			}
+	// Buckets and cache are shared, so we don't have to clone or download
+	// things multiple times.
+	for _, dirs := range [][2]string{
+		{defaultScoop.CacheDir(), tempScoop.CacheDir()},
+		{defaultScoop.BucketDir(), tempScoop.BucketDir()},
+	} {
+		if err := tempScoop.Linker.LinkDir(dirs[0], dirs[1]); err != nil {
+			return nil, fmt.Errorf("error linking shared dir: %w", err)
+		}
+	}
+
+	state, err := loadShellState(tempScoop)
+	if err != nil {
+		return nil, err
+	}
+	if projectHash != "" && state.ProjectHash == projectHash {
+		fmt.Println("Environment is up to date")
+		return tempScoop, nil
+	}
 
-			// Scoop forcibly adds the shim dir to the path in a persistent
-			// manner. We can't prevent this, but we can restore the previous
-			// state. Additionally some apps also have "env_set" and
-			// "env_add_path" instructions, which also do persistent changes.
-			restoreEnvVars(envToRestore)
+	if err := syncShellApps(tempScoop, &state, apps); err != nil {
+		return nil, err
+	}
 
-			if err != nil {
-				return fmt.Errorf("error installing dependencies: %w", err)
+	state.ProjectHash = projectHash
+	state.Apps = apps
+	if err := saveShellState(tempScoop, state); err != nil {
+		return nil, err
+	}
+	return tempScoop, nil
+}
+
+// syncShellApps uninstalls all apps that were previously installed, but
+// aren't requested anymore, and installs the newly requested ones. Apps are
+// compared by their full identifier, so changing a version pin causes a
+// reinstall. The state is saved after each change, so that apps installed by
+// a failing setup are still known to the next one.
+func syncShellApps(shellScoop *scoop.Scoop, state *shellState, apps []string) error {
+	// Until everything is in sync, the next setup mustn't be skipped.
+	state.ProjectHash = ""
+	if err := saveShellState(shellScoop, *state); err != nil {
+		return err
+	}
+
+	isRequested := func(apps []string, app string) bool {
+		return slices.ContainsFunc(apps, func(other string) bool {
+			return strings.EqualFold(app, other)
+		})
+	}
+
+	for _, previousApp := range slices.Clone(state.Apps) {
+		if isRequested(apps, previousApp) {
+			continue
+		}
+
+		installedApp, err := shellScoop.FindInstalledApp(previousApp)
+		if err != nil {
+			return fmt.Errorf("error finding installed app: %w", err)
+		}
+		if installedApp != nil {
+			if err := installedApp.LoadDetails(scoop.DetailFieldsAll...); err != nil {
+				return fmt.Errorf("error loading app details: %w", err)
 			}
 
-			var envPowershellSetup strings.Builder
-			envPowershellSetup.WriteString(shell)
-			envPowershellSetup.WriteString(" -NoExit -Command '")
-			for _, envEntry := range tempEnv {
-				envPowershellSetup.WriteString(`$env:`)
-				envPowershellSetup.WriteString(envEntry.Key)
-				envPowershellSetup.WriteString(`=''`)
-				envPowershellSetup.WriteString(envEntry.Value)
-				envPowershellSetup.WriteString(`'';`)
+			fmt.Printf("Uninstalling '%s' ...\n", previousApp)
+			if err := shellScoop.Uninstall(installedApp, installedApp.Architecture); err != nil {
+				return fmt.Errorf("error uninstalling '%s': %w", previousApp, err)
 			}
-			envPowershellSetup.WriteString(`$env:PATH=''` + newTempPath + `;$PATH'''`)
-
-			// Workaround, as starting a subshell on windows doesn't seem to be
-			// so easy after all.
-			if err := os.WriteFile(
-				"shell.ps1",
-				// Not only do we need to temporary add our shims to the path,
-				// we also need to reset the temporary path, as this is carried
-				// over out of the subshell, why ever ...
-				[]byte(envPowershellSetup.String()),
-				0o700,
-			); err != nil {
-				return fmt.Errorf("error creating shell script: %w", err)
+			if err := windows.ForceRemoveAll(filepath.Join(shellScoop.AppDir(), installedApp.Name)); err != nil {
+				return fmt.Errorf("error deleting installation files: %w", err)
 			}
-			return nil
-		}),
-	})
+		}
 
-	return cmd
+		state.Apps = slices.DeleteFunc(state.Apps, func(app string) bool {
+			return strings.EqualFold(app, previousApp)
+		})
+		if err := saveShellState(shellScoop, *state); err != nil {
+			return err
+		}
+	}
+
+	var installFailed bool
+	for _, app := range apps {
+		if isRequested(state.Apps, app) {
+			continue
+		}
+
+		// If a previous setup failed half way, the app might already be
+		// installed.
+		if err := shellScoop.Install(app, SystemArchitecture); err != nil && !errors.Is(err, scoop.ErrAlreadyInstalled) {
+			fmt.Printf("error installing '%s': %s\n", app, err)
+			installFailed = true
+		}
+
+		// Failed installations might have gotten far enough to require an
+		// uninstallation later on.
+		installedApp, err := shellScoop.FindInstalledApp(app)
+		if err != nil {
+			return fmt.Errorf("error finding installed app: %w", err)
+		}
+		if installedApp == nil {
+			continue
+		}
+		state.Apps = append(state.Apps, app)
+		if err := saveShellState(shellScoop, *state); err != nil {
+			return err
+		}
+	}
+	if installFailed {
+		return errors.New("error installing dependencies")
+	}
+	return nil
+}
+
+// shellEnvironment computes the PATH additions and environment variables
+// required for the given apps installed in the shell scoop. All changes the
+// apps would make to the persistent environment are returned instead. The
+// shim dir always comes last. The extra variables are appended, ordered by
+// key.
+func shellEnvironment(
+	shellScoop *scoop.Scoop,
+	apps []string,
+	extraEnv map[string]string,
+) ([]string, []scoop.EnvVar, error) {
+	var (
+		pathAdditions []string
+		env           []scoop.EnvVar
+	)
+	for _, dependency := range apps {
+		// Some apps require extra environment variables and some apps use
+		// env_add_path instead of specifying shims.
+		app, err := shellScoop.FindInstalledApp(dependency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error finding installed app: %w", err)
+		}
+		if app == nil {
+			return nil, nil, fmt.Errorf("app '%s' isn't installed", dependency)
+		}
+		if err := app.LoadDetails(
+			scoop.DetailFieldVersion,
+			scoop.DetailFieldEnvSet,
+			scoop.DetailFieldEnvAddPath,
+		); err != nil {
+			return nil, nil, fmt.Errorf("error loading app details: %w", err)
+		}
+
+		// Apps passed later take precedence, but the order of the entries
+		// of each app is kept.
+		pathAdditions = append(shellScoop.AppPathEntries(app.App), pathAdditions...)
+
+		env = append(env, shellScoop.AppEnvVars(app)...)
+	}
+
+	// Maps aren't ordered, but we want stable scripts.
+	for _, key := range slices.Sorted(maps.Keys(extraEnv)) {
+		env = append(env, scoop.EnvVar{Key: key, Value: extraEnv[key]})
+	}
+
+	return append(pathAdditions, shellScoop.ShimDir()), env, nil
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+)
+
+// activationScript generates a script, that starts a subshell with the
+// environment of a `spoon shell` applied.
+type activationScript struct {
+	// FileName is the name of the generated script.
+	FileName string
+	// Usage is the command the user has to run in order to activate the
+	// environment.
+	Usage string
+	// Generate creates the script content. The path additions are in order of
+	// precedence.
+	Generate func(pathAdditions []string, env []scoop.EnvVar) string
+}
+
+const activationComment = "Generated by spoon, starts a subshell with the environment applied."
+
+var activationScripts = map[string]activationScript{
+	"powershell": powershellActivationScript("powershell.exe"),
+	"pwsh":       powershellActivationScript("pwsh.exe"),
+	"cmd": {
+		FileName: "shell.bat",
+		Usage:    "shell.bat",
+		Generate: func(pathAdditions []string, env []scoop.EnvVar) string {
+			var script strings.Builder
+			script.WriteString("@echo off\r\n")
+			script.WriteString("rem " + activationComment + "\r\n")
+			script.WriteString("setlocal\r\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "set \"%s=%s\"\r\n", envVar.Key, windows.EscapeBatch(envVar.Value, true))
+			}
+			fmt.Fprintf(&script, "set \"PATH=%s;%%PATH%%\"\r\n", windows.EscapeBatch(strings.Join(pathAdditions, ";"), true))
+			script.WriteString("cmd /k\r\n")
+			script.WriteString("endlocal\r\n")
+			return script.String()
+		},
+	},
+	"bash": {
+		FileName: "shell.sh",
+		Usage:    "./shell.sh",
+		Generate: func(pathAdditions []string, env []scoop.EnvVar) string {
+			var script strings.Builder
+			script.WriteString("#!/bin/sh\n")
+			script.WriteString("# " + activationComment + "\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "export %s=%s\n", envVar.Key, quotePosix(envVar.Value))
+			}
+			// Only the PATH is translated, since MSYS translates it back
+			// when invoking Windows executables. Other variables are
+			// passed as they are.
+			posixPaths := make([]string, 0, len(pathAdditions))
+			for _, path := range pathAdditions {
+				posixPaths = append(posixPaths, toMsysPath(path))
+			}
+			fmt.Fprintf(&script, "export PATH=%s:\"$PATH\"\n", quotePosix(strings.Join(posixPaths, ":")))
+			script.WriteString("exec \"${SHELL:-/bin/bash}\" -i\n")
+			return script.String()
+		},
+	},
+	"fish": {
+		FileName: "shell.fish",
+		Usage:    "fish shell.fish",
+		Generate: func(pathAdditions []string, env []scoop.EnvVar) string {
+			var script strings.Builder
+			script.WriteString("# " + activationComment + "\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "set -gx %s %s\n", envVar.Key, quoteFish(envVar.Value))
+			}
+			script.WriteString("set -gx PATH")
+			for _, path := range pathAdditions {
+				script.WriteString(" " + quoteFish(toMsysPath(path)))
+			}
+			script.WriteString(" $PATH\n")
+			script.WriteString("exec fish\n")
+			return script.String()
+		},
+	},
+	"nu": {
+		FileName: "shell.nu",
+		Usage:    "nu shell.nu",
+		Generate: func(pathAdditions []string, env []scoop.EnvVar) string {
+			var script strings.Builder
+			script.WriteString("# " + activationComment + "\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "$env.%s = %s\n", envVar.Key, quoteNu(envVar.Value))
+			}
+			script.WriteString("$env.Path = ($env.Path | prepend [")
+			for index, path := range pathAdditions {
+				if index > 0 {
+					script.WriteString(" ")
+				}
+				script.WriteString(quoteNu(path))
+			}
+			script.WriteString("])\n")
+			script.WriteString("exec nu\n")
+			return script.String()
+		},
+	},
+}
+
+func powershellActivationScript(executable string) activationScript {
+	return activationScript{
+		FileName: "shell.ps1",
+		Usage:    `.\shell.ps1`,
+		Generate: func(pathAdditions []string, env []scoop.EnvVar) string {
+			// Scripts modify the environment of the calling session, so we
+			// restore it after the subshell exits.
+			var script strings.Builder
+			script.WriteString("# " + activationComment + "\n")
+			script.WriteString("$previousEnv = @{\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "    %s = $env:%s\n", quotePowershell(envVar.Key), envVar.Key)
+			}
+			script.WriteString("    'PATH' = $env:PATH\n")
+			script.WriteString("}\n")
+			script.WriteString("try {\n")
+			for _, envVar := range env {
+				fmt.Fprintf(&script, "    $env:%s = %s\n", envVar.Key, quotePowershell(envVar.Value))
+			}
+			fmt.Fprintf(&script, "    $env:PATH = %s + $env:PATH\n", quotePowershell(strings.Join(pathAdditions, ";")+";"))
+			fmt.Fprintf(&script, "    & %s -NoLogo -NoExit\n", executable)
+			script.WriteString("} finally {\n")
+			script.WriteString("    foreach ($entry in $previousEnv.GetEnumerator()) {\n")
+			script.WriteString("        [Environment]::SetEnvironmentVariable($entry.Key, $entry.Value)\n")
+			script.WriteString("    }\n")
+			script.WriteString("}\n")
+			return script.String()
+		},
+	}
+}
+
+// activationScriptFor returns the script for the given shell, which is either a
+// name or an executable name, such as `pwsh.exe`. If the shell isn't
+// supported, powershell is used.
+func activationScriptFor(shell string) activationScript {
+	shell = strings.ToLower(filepath.Base(shell))
+	shell = strings.TrimSuffix(shell, ".exe")
+	if shell == "sh" {
+		shell = "bash"
+	}
+
+	if script, ok := activationScripts[shell]; ok {
+		return script
+	}
+	return activationScripts["powershell"]
+}
+
+func activationShellNames() []string {
+	return []string{"powershell", "pwsh", "cmd", "bash", "fish", "nu"}
+}
+
+var drivePathRegex = regexp.MustCompile(`^([a-zA-Z]):[\\/]?`)
+
+// toMsysPath turns a windows path such as `C:\Users\me` into the form MSYS
+// based shells (git-bash) use, such as `/c/Users/me`.
+func toMsysPath(path string) string {
+	path = drivePathRegex.ReplaceAllStringFunc(path, func(drive string) string {
+		return "/" + strings.ToLower(drive[:1]) + "/"
+	})
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+func quotePosix(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func quoteFish(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func quotePowershell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quoteNu uses raw strings, as they don't allow any escaping or
+// interpolation. The number of hashes is increased until the value can't
+// terminate the string.
+func quoteNu(value string) string {
+	hashes := "#"
+	for strings.Contains(value, "'"+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "'" + value + "'" + hashes
+}
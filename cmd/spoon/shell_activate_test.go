@@ -0,0 +1,144 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Quote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		quote    func(string) string
+		value    string
+		expected string
+	}{
+		{name: "posix", quote: quotePosix, value: `C:\a b`, expected: `'C:\a b'`},
+		{name: "posix quote", quote: quotePosix, value: `it's $HOME`, expected: `'it'\''s $HOME'`},
+		{name: "fish", quote: quoteFish, value: `/c/a b`, expected: `'/c/a b'`},
+		{name: "fish escapes", quote: quoteFish, value: `C:\it's`, expected: `'C:\\it\'s'`},
+		{name: "powershell", quote: quotePowershell, value: `it's $env:X`, expected: `'it''s $env:X'`},
+		{name: "nu", quote: quoteNu, value: `C:\a b`, expected: `r#'C:\a b'#`},
+		{name: "nu hash", quote: quoteNu, value: `it'#s`, expected: `r##'it'#s'##`},
+		{name: "nu hashes", quote: quoteNu, value: `'##`, expected: `r###''##'###`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, test.quote(test.value))
+		})
+	}
+}
+
+func Test_ToMsysPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: `C:\Users\me`, expected: `/c/Users/me`},
+		{path: `d:/tools`, expected: `/d/tools`},
+		{path: `E:`, expected: `/e/`},
+		{path: `relative\dir`, expected: `relative/dir`},
+		{path: `/already/posix`, expected: `/already/posix`},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, toMsysPath(test.path), test.path)
+	}
+}
+
+func Test_ActivationScriptFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		shell    string
+		fileName string
+	}{
+		{shell: "cmd.exe", fileName: "shell.bat"},
+		{shell: "C:/Program Files/Git/usr/bin/bash.exe", fileName: "shell.sh"},
+		{shell: "sh", fileName: "shell.sh"},
+		{shell: "FISH.EXE", fileName: "shell.fish"},
+		{shell: "nu", fileName: "shell.nu"},
+		{shell: "pwsh.exe", fileName: "shell.ps1"},
+		{shell: "unknown.exe", fileName: "shell.ps1"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.fileName, activationScriptFor(test.shell).FileName, test.shell)
+	}
+
+	for _, name := range activationShellNames() {
+		require.Contains(t, activationScripts, name)
+	}
+}
+
+func Test_ActivationScripts_Generate(t *testing.T) {
+	t.Parallel()
+
+	pathAdditions := []string{`C:\spoon\apps\go\current\bin`, `C:\100% sure`}
+	env := []scoop.EnvVar{{Key: "GOPATH", Value: `C:\it's & go`}}
+
+	tests := []struct {
+		shell    string
+		expected string
+	}{
+		{
+			shell: "cmd",
+			expected: "@echo off\r\n" +
+				"rem " + activationComment + "\r\n" +
+				"setlocal\r\n" +
+				"set \"GOPATH=C:\\it's & go\"\r\n" +
+				"set \"PATH=C:\\spoon\\apps\\go\\current\\bin;C:\\100%% sure;%PATH%\"\r\n" +
+				"cmd /k\r\n" +
+				"endlocal\r\n",
+		},
+		{
+			shell: "bash",
+			expected: "#!/bin/sh\n" +
+				"# " + activationComment + "\n" +
+				"export GOPATH='C:\\it'\\''s & go'\n" +
+				"export PATH='/c/spoon/apps/go/current/bin:/c/100% sure':\"$PATH\"\n" +
+				"exec \"${SHELL:-/bin/bash}\" -i\n",
+		},
+		{
+			shell: "fish",
+			expected: "# " + activationComment + "\n" +
+				"set -gx GOPATH 'C:\\\\it\\'s & go'\n" +
+				"set -gx PATH '/c/spoon/apps/go/current/bin' '/c/100% sure' $PATH\n" +
+				"exec fish\n",
+		},
+		{
+			shell: "nu",
+			expected: "# " + activationComment + "\n" +
+				"$env.GOPATH = r#'C:\\it's & go'#\n" +
+				"$env.Path = ($env.Path | prepend [r#'C:\\spoon\\apps\\go\\current\\bin'# r#'C:\\100% sure'#])\n" +
+				"exec nu\n",
+		},
+		{
+			shell: "pwsh",
+			expected: "# " + activationComment + "\n" +
+				"$previousEnv = @{\n" +
+				"    'GOPATH' = $env:GOPATH\n" +
+				"    'PATH' = $env:PATH\n" +
+				"}\n" +
+				"try {\n" +
+				"    $env:GOPATH = 'C:\\it''s & go'\n" +
+				"    $env:PATH = 'C:\\spoon\\apps\\go\\current\\bin;C:\\100% sure;' + $env:PATH\n" +
+				"    & pwsh.exe -NoLogo -NoExit\n" +
+				"} finally {\n" +
+				"    foreach ($entry in $previousEnv.GetEnumerator()) {\n" +
+				"        [Environment]::SetEnvironmentVariable($entry.Key, $entry.Value)\n" +
+				"    }\n" +
+				"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, activationScripts[test.shell].Generate(pathAdditions, env))
+		})
+	}
+}
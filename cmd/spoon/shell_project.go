@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+)
+
+// projectFileName is the name of the file defining the `spoon shell`
+// environment of a project. It is meant to be checked in.
+const projectFileName = "spoon.json"
+
+// projectFile defines a reproducible `spoon shell` environment.
+type projectFile struct {
+	// Apps are app identifiers in the form of `[bucket/]app[@version]`.
+	Apps []string `json:"apps"`
+	// Env are additional environment variables set in the shell.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// readProjectFile parses the project file and additionally returns a hash of
+// its content, allowing to detect changes.
+func readProjectFile(path string) (*projectFile, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading project file: %w", err)
+	}
+
+	var project projectFile
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, "", fmt.Errorf("error parsing project file: %w", err)
+	}
+	if len(project.Apps) == 0 {
+		return nil, "", fmt.Errorf("project file '%s' doesn't define any apps", path)
+	}
+
+	hash := sha256.Sum256(data)
+	return &project, hex.EncodeToString(hash[:]), nil
+}
+
+// readWorkDirProjectFile reads the project file in the current directory.
+func readWorkDirProjectFile() (*projectFile, string, error) {
+	project, hash, err := readProjectFile(projectFileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", fmt.Errorf("no apps passed and no '%s' found in the current directory", projectFileName)
+		}
+		return nil, "", err
+	}
+	return project, hash, nil
+}
+
+// setupProjectShell sets up the environment defined by the project file in
+// the current directory.
+func setupProjectShell(shell string) error {
+	project, hash, err := readWorkDirProjectFile()
+	if err != nil {
+		return err
+	}
+
+	return setupShell(shell, project.Apps, project.Env, hash)
+}
+
+// shellState is stored inside of the shell scoop and describes what has been
+// installed into it.
+type shellState struct {
+	// ProjectHash is the hash of the project file used for the last
+	// successful setup. It is empty if the apps were passed explicitly or the
+	// last setup failed.
+	ProjectHash string `json:"project_hash,omitempty"`
+	// Apps are the identifiers of all apps installed, as they were requested.
+	Apps []string `json:"apps"`
+}
+
+func shellStatePath(shellScoop *scoop.Scoop) string {
+	return filepath.Join(shellScoop.SpoonDir(), "shell.json")
+}
+
+// loadShellState returns the state of the last setup, or an empty state if
+// there was no successful setup yet.
+func loadShellState(shellScoop *scoop.Scoop) (shellState, error) {
+	var state shellState
+	data, err := os.ReadFile(shellStatePath(shellScoop))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("error reading shell state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("error parsing shell state: %w", err)
+	}
+	return state, nil
+}
+
+func saveShellState(shellScoop *scoop.Scoop, state shellState) error {
+	if err := os.MkdirAll(shellScoop.SpoonDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating spoon dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error encoding shell state: %w", err)
+	}
+	if err := os.WriteFile(shellStatePath(shellScoop), data, 0o600); err != nil {
+		return fmt.Errorf("error writing shell state: %w", err)
+	}
+	return nil
+}
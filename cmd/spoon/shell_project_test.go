@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/scooptest"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadProjectFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	project, hash, err := readProjectFile(write("a.json", `{"apps": ["go"], "env": {"A": "b"}}`))
+	require.NoError(t, err)
+	require.Equal(t, &projectFile{Apps: []string{"go"}, Env: map[string]string{"A": "b"}}, project)
+
+	// Any change, even formatting, changes the hash.
+	_, sameHash, err := readProjectFile(write("b.json", `{"apps": ["go"], "env": {"A": "b"}}`))
+	require.NoError(t, err)
+	require.Equal(t, hash, sameHash)
+	_, otherHash, err := readProjectFile(write("c.json", `{"apps": ["go"],  "env": {"A": "b"}}`))
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+
+	_, _, err = readProjectFile(write("empty.json", `{"apps": []}`))
+	require.Error(t, err)
+	_, _, err = readProjectFile(write("invalid.json", `{"apps": `))
+	require.Error(t, err)
+	_, _, err = readProjectFile(filepath.Join(dir, "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_ShellState(t *testing.T) {
+	t.Parallel()
+
+	shellScoop := scoop.NewCustomScoop(t.TempDir())
+	state, err := loadShellState(shellScoop)
+	require.NoError(t, err)
+	require.Equal(t, shellState{}, state)
+
+	require.NoError(t, saveShellState(shellScoop, shellState{
+		ProjectHash: "hash",
+		Apps:        []string{"main/go@1.22.1"},
+	}))
+	state, err = loadShellState(shellScoop)
+	require.NoError(t, err)
+	require.Equal(t, shellState{ProjectHash: "hash", Apps: []string{"main/go@1.22.1"}}, state)
+}
+
+// failingScriptRunner fails all scripts of the given app.
+type failingScriptRunner struct {
+	app string
+}
+
+func (runner failingScriptRunner) Run(script *scoop.Script) error {
+	if script.App == runner.app {
+		return errors.New("script failed")
+	}
+	return nil
+}
+
+func Test_SyncShellApps(t *testing.T) {
+	t.Parallel()
+
+	// Downloads of missing.zip fail.
+	archive := scooptest.ServeZip(t, map[string]string{"tool.exe": "binary"})
+
+	shellScoop := scoop.NewCustomScoop(t.TempDir())
+	shellScoop.Isolated = true
+	shellScoop.EnvStore = scoop.NewMemoryEnvStore(nil)
+	shellScoop.ScriptRunner = failingScriptRunner{app: "broken"}
+
+	for name, manifest := range map[string]string{
+		"good":   `{"version": "1.0.0", "url": "%[1]s/good.zip", "hash": "%[2]s"}`,
+		"broken": `{"version": "1.0.0", "url": "%[1]s/broken.zip", "hash": "%[2]s", "post_install": "exit 1"}`,
+		"absent": `{"version": "1.0.0", "url": "%[1]s/missing.zip", "hash": "%[2]s"}`,
+	} {
+		scooptest.WriteManifest(t, shellScoop, name, fmt.Sprintf(manifest, archive.URL, archive.Hash))
+	}
+
+	// Apps installed by a failing setup are recorded, even if they failed
+	// half way.
+	state := shellState{ProjectHash: "old"}
+	require.Error(t, syncShellApps(shellScoop, &state, []string{"good", "broken", "absent"}))
+	require.Equal(t, []string{"good", "broken"}, state.Apps)
+	require.Empty(t, state.ProjectHash)
+
+	saved, err := loadShellState(shellScoop)
+	require.NoError(t, err)
+	require.Equal(t, state, saved)
+
+	// Apps no longer requested are uninstalled, no matter whether the setup
+	// installing them succeeded.
+	require.NoError(t, syncShellApps(shellScoop, &state, []string{"good"}))
+	require.Equal(t, []string{"good"}, state.Apps)
+	require.NoDirExists(t, filepath.Join(shellScoop.AppDir(), "broken"))
+	require.DirExists(t, filepath.Join(shellScoop.AppDir(), "good"))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+// Before shell environments were isolated, apps were installed into them
+// just like into the default scoop. Therefore their path entries and
+// variables were added to the persistent user environment and only removed
+// again if the setup wasn't interrupted.
+
+// isInShellRoot checks whether the path points into any of the roots.
+func isInShellRoot(path string, roots []string) bool {
+	normalised := windows.NormalisePath(path)
+	return slices.ContainsFunc(roots, func(root string) bool {
+		normalisedRoot := windows.NormalisePath(root)
+		return normalised == normalisedRoot || strings.HasPrefix(normalised, normalisedRoot+`\`)
+	})
+}
+
+// recoverUserEnv removes all path entries pointing into the given shell
+// roots from the persistent user environment, as well as all variables
+// pointing into them. Everything else is left untouched.
+func recoverUserEnv(defaultScoop *scoop.Scoop, roots []string) error {
+	values, err := defaultScoop.EnvStore.Values()
+	if err != nil {
+		return fmt.Errorf("error retrieving user environment: %w", err)
+	}
+
+	changes := make(map[string]string)
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		if !strings.EqualFold(key, "Path") {
+			if isInShellRoot(value, roots) {
+				fmt.Printf("Removing variable '%s'\n", key)
+				// An empty value deletes the variable.
+				changes[key] = ""
+			}
+			continue
+		}
+
+		path := windows.ParsePath(value)
+		kept := slices.DeleteFunc(slices.Clone(path), func(entry string) bool {
+			if isInShellRoot(entry, roots) {
+				fmt.Printf("Removing '%s' from the path\n", entry)
+				return true
+			}
+			return false
+		})
+		if len(kept) != len(path) {
+			changes[key] = kept.String()
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("Nothing to recover")
+		return nil
+	}
+	if err := defaultScoop.EnvStore.Set(changes); err != nil {
+		return fmt.Errorf("error restoring user environment: %w", err)
+	}
+	return nil
+}
+
+func shellRecoverCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "recover [root...]",
+		Short: "Remove leftovers of interrupted shell setups of older spoon versions from the user environment",
+		Long: strings.TrimSpace(`
+Remove leftovers of interrupted shell setups of older spoon versions from the
+user environment.
+
+Older versions of spoon installed apps into shell environments just like into
+the default scoop, adding their path entries and variables to the persistent
+user environment. These were only removed again, if the setup wasn't
+interrupted.
+
+This command removes all path entries and variables pointing into registered
+environments or the given environment roots, such as the '.scoop' dir of a
+project. Everything else is left untouched.`),
+		Example: cli.FormatUsageExample(
+			"spoon shell recover",
+			"spoon shell recover ./.scoop",
+		),
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			registrations, err := loadShellRegistry(defaultScoop)
+			if err != nil {
+				return err
+			}
+			roots := make([]string, 0, len(registrations)+len(args))
+			for _, registration := range registrations {
+				roots = append(roots, registration.Root)
+			}
+			for _, arg := range args {
+				root, err := filepath.Abs(arg)
+				if err != nil {
+					return fmt.Errorf("error getting absolute path: %w", err)
+				}
+				roots = append(roots, root)
+			}
+
+			return recoverUserEnv(defaultScoop, roots)
+		}),
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsInShellRoot(t *testing.T) {
+	t.Parallel()
+
+	roots := []string{`C:\project\.scoop`}
+	require.True(t, isInShellRoot(`C:\project\.scoop`, roots))
+	require.True(t, isInShellRoot(`c:/Project/.scoop/shims/`, roots))
+	require.False(t, isInShellRoot(`C:\project\.scoop2`, roots))
+	require.False(t, isInShellRoot(`C:\project`, roots))
+	require.False(t, isInShellRoot("", roots))
+}
+
+func Test_RecoverUserEnv(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	store := scoop.NewMemoryEnvStore(map[string]string{
+		"PATH":      `"C:\project\.scoop\shims";"C:\bin";"C:\project\.scoop\apps\go\current\bin"`,
+		"JAVA_HOME": `C:\project\.scoop\apps\jdk\current`,
+		"GOPATH":    `C:\go`,
+		"TOKEN":     "secret",
+	})
+	defaultScoop.EnvStore = store
+
+	require.NoError(t, recoverUserEnv(defaultScoop, []string{`C:\project\.scoop`, `C:\other\.scoop`}))
+	values, err := store.Values()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"PATH":   `"C:\bin"`,
+		"GOPATH": `C:\go`,
+		"TOKEN":  "secret",
+	}, values)
+
+	// Nothing left to do.
+	require.NoError(t, recoverUserEnv(defaultScoop, []string{`C:\project\.scoop`}))
+	values, err = store.Values()
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+}
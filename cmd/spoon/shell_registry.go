@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+// shellRegistration describes an environment created by `spoon shell`. All
+// environments are registered in the default scoop, so that they can be
+// listed and garbage collected.
+type shellRegistration struct {
+	// Root is the scoop root of the environment. For environments set up by
+	// the user, this is the `.scoop` dir inside of the project dir.
+	Root string `json:"root"`
+	// Apps are the identifiers of all apps requested.
+	Apps     []string  `json:"apps"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+func shellRegistryPath(defaultScoop *scoop.Scoop) string {
+	return filepath.Join(defaultScoop.SpoonDir(), "shells.json")
+}
+
+// loadShellRegistry returns all registered environments, or an empty list if
+// none have been registered yet.
+func loadShellRegistry(defaultScoop *scoop.Scoop) ([]shellRegistration, error) {
+	data, err := os.ReadFile(shellRegistryPath(defaultScoop))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading shell registry: %w", err)
+	}
+
+	var registrations []shellRegistration
+	if err := json.Unmarshal(data, &registrations); err != nil {
+		return nil, fmt.Errorf("error parsing shell registry: %w", err)
+	}
+	return registrations, nil
+}
+
+func saveShellRegistry(defaultScoop *scoop.Scoop, registrations []shellRegistration) error {
+	if err := os.MkdirAll(defaultScoop.SpoonDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating spoon dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(registrations, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error encoding shell registry: %w", err)
+	}
+	if err := os.WriteFile(shellRegistryPath(defaultScoop), data, 0o600); err != nil {
+		return fmt.Errorf("error writing shell registry: %w", err)
+	}
+	return nil
+}
+
+// sameShellRoot compares case insensitively, as Windows paths are case
+// insensitive.
+func sameShellRoot(a, b string) bool {
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
+
+// shellRegistryLockTimeout is the maximum time to wait for the registry
+// lock. Locks older than that are considered stale, as the registry is only
+// locked for reading and writing it.
+const shellRegistryLockTimeout = 10 * time.Second
+
+// lockShellRegistry prevents concurrent modifications of the registry by
+// multiple spoon processes, such as parallel `spoon shell run` invocations.
+// The returned function releases the lock.
+func lockShellRegistry(defaultScoop *scoop.Scoop) (func(), error) {
+	if err := os.MkdirAll(defaultScoop.SpoonDir(), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating spoon dir: %w", err)
+	}
+
+	path := shellRegistryPath(defaultScoop) + ".lock"
+	deadline := time.Now().Add(shellRegistryLockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error locking shell registry: %w", err)
+		}
+
+		// The owner of the lock probably crashed.
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > shellRegistryLockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for shell registry lock '%s'", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// updateShellRegistry loads the registry, applies update and saves the
+// result, while holding the registry lock.
+func updateShellRegistry(
+	defaultScoop *scoop.Scoop,
+	update func(registrations []shellRegistration) []shellRegistration,
+) error {
+	unlock, err := lockShellRegistry(defaultScoop)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	registrations, err := loadShellRegistry(defaultScoop)
+	if err != nil {
+		return err
+	}
+	return saveShellRegistry(defaultScoop, update(registrations))
+}
+
+// registerShell adds the environment at root to the registry or marks it as
+// used, if it is already registered.
+func registerShell(defaultScoop *scoop.Scoop, root string, apps []string) error {
+	return updateShellRegistry(defaultScoop, func(registrations []shellRegistration) []shellRegistration {
+		now := time.Now()
+		index := slices.IndexFunc(registrations, func(registration shellRegistration) bool {
+			return sameShellRoot(registration.Root, root)
+		})
+		if index == -1 {
+			registrations = append(registrations, shellRegistration{
+				Root:    root,
+				Created: now,
+			})
+			index = len(registrations) - 1
+		}
+		registrations[index].Apps = apps
+		registrations[index].LastUsed = now
+		return registrations
+	})
+}
+
+// unregisterShell removes the environments at the given roots from the
+// registry. The environments themselves aren't touched.
+func unregisterShell(defaultScoop *scoop.Scoop, roots ...string) error {
+	return updateShellRegistry(defaultScoop, func(registrations []shellRegistration) []shellRegistration {
+		return slices.DeleteFunc(registrations, func(registration shellRegistration) bool {
+			return slices.ContainsFunc(roots, func(root string) bool {
+				return sameShellRoot(registration.Root, root)
+			})
+		})
+	})
+}
+
+// collectShellGarbage unregisters all environments that don't exist anymore.
+// If maxAge is passed, environments that haven't been set up or run for that
+// long are deleted. Since entering an environment via its activation script
+// doesn't count as usage, this only happens on request.
+func collectShellGarbage(defaultScoop *scoop.Scoop, maxAge time.Duration) error {
+	var (
+		missing []string
+		expired []shellRegistration
+	)
+	registrations, err := loadShellRegistry(defaultScoop)
+	if err != nil {
+		return err
+	}
+	for _, registration := range registrations {
+		if _, err := os.Stat(registration.Root); os.IsNotExist(err) {
+			fmt.Printf("Unregistering '%s', as it doesn't exist anymore\n", registration.Root)
+			missing = append(missing, registration.Root)
+			continue
+		}
+		if maxAge > 0 && time.Since(registration.LastUsed) > maxAge {
+			expired = append(expired, registration)
+		}
+	}
+
+	// Deleting takes a while, so we don't hold the lock in the meantime.
+	deleted := missing
+	var deleteFailed bool
+	for _, registration := range expired {
+		fmt.Printf("Deleting '%s', as it hasn't been used since %s\n",
+			registration.Root, registration.LastUsed.Format(time.DateTime))
+		if err := deleteShellEnvironment(registration.Root); err != nil {
+			fmt.Println(err)
+			deleteFailed = true
+			continue
+		}
+		deleted = append(deleted, registration.Root)
+	}
+
+	if err := unregisterShell(defaultScoop, deleted...); err != nil {
+		return err
+	}
+	if deleteFailed {
+		return errors.New("error deleting environments")
+	}
+	return nil
+}
+
+// deleteShellEnvironment deletes the environment at root. If root is the
+// `.scoop` dir of a project, the activation scripts next to it are deleted as
+// well.
+func deleteShellEnvironment(root string) error {
+	if filepath.Base(root) == ".scoop" {
+		// Delete shellscripts first, as nothing can go wrong.
+		for _, script := range activationScripts {
+			path := filepath.Join(filepath.Dir(root), script.FileName)
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("error deleting '%s': %w", path, err)
+			}
+		}
+	}
+
+	// Buckets and cache are links to the dirs of the default scoop, so we
+	// mustn't touch their targets.
+	if err := windows.ForceRemoveAll(root); err != nil {
+		return fmt.Errorf("error deleting '%s': %w", root, err)
+	}
+	return nil
+}
+
+func shellListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all environments created via spoon shell",
+		Example: cli.FormatUsageExample(
+			"spoon shell list",
+			"spoon shell list --out-format json",
+		),
+		Args: cobra.NoArgs,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+			registrations, err := loadShellRegistry(defaultScoop)
+			if err != nil {
+				return err
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				if registrations == nil {
+					registrations = []shellRegistration{}
+				}
+				if err := json.NewEncoder(os.Stdout).Encode(registrations); err != nil {
+					return fmt.Errorf("error encoding environments: %w", err)
+				}
+			case "plain":
+				tbl, _, _ := cli.CreateTable("Path", "Apps", "Created", "Last used", "Info")
+				for _, registration := range registrations {
+					var info string
+					if _, err := os.Stat(registration.Root); os.IsNotExist(err) {
+						info = "Missing"
+					}
+					tbl.AddRow(
+						registration.Root,
+						strings.Join(registration.Apps, ","),
+						registration.Created.Format(time.DateTime),
+						registration.LastUsed.Format(time.DateTime),
+						info,
+					)
+				}
+
+				fmt.Print("\n")
+				tbl.Print()
+				fmt.Print("\n")
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
+			return nil
+		}),
+	}
+	cmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	cmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func shellGCCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Unregister orphaned environments and delete ones that haven't been used in a while",
+		Long: strings.TrimSpace(`
+Unregister orphaned environments and delete ones that haven't been used in a
+while.
+
+Environments that don't exist anymore are unregistered. If --days is passed,
+environments that haven't been set up or run for the given amount of days are
+deleted, including their activation scripts. Note that entering an environment
+via its activation script doesn't count as usage.`),
+		Example: cli.FormatUsageExample(
+			"spoon shell gc",
+			"spoon shell gc --days 30",
+		),
+		Args: cobra.NoArgs,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			var maxAge time.Duration
+			if cmd.Flags().Changed("days") {
+				days := must(cmd.Flags().GetInt("days"))
+				if days <= 0 {
+					return errors.New("days must be positive")
+				}
+				maxAge = time.Duration(days) * 24 * time.Hour
+			}
+
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+			return collectShellGarbage(defaultScoop, maxAge)
+		}),
+	}
+	cmd.Flags().Int("days", 0, "Delete environments that haven't been set up or run for the given amount of days")
+	return cmd
+}
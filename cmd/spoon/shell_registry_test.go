@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RegisterShell_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- registerShell(defaultScoop, fmt.Sprintf("/shell/%d", i), []string{"go"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	registrations, err := loadShellRegistry(defaultScoop)
+	require.NoError(t, err)
+	require.Len(t, registrations, 20)
+	require.NoFileExists(t, shellRegistryPath(defaultScoop)+".lock")
+}
+
+func Test_LockShellRegistry_Stale(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	lockPath := shellRegistryPath(defaultScoop) + ".lock"
+	require.NoError(t, os.MkdirAll(defaultScoop.SpoonDir(), os.ModePerm))
+	require.NoError(t, os.WriteFile(lockPath, nil, 0o600))
+	stale := time.Now().Add(-2 * shellRegistryLockTimeout)
+	require.NoError(t, os.Chtimes(lockPath, stale, stale))
+
+	unlock, err := lockShellRegistry(defaultScoop)
+	require.NoError(t, err)
+	unlock()
+	require.NoFileExists(t, lockPath)
+}
+
+func Test_CollectShellGarbage(t *testing.T) {
+	t.Parallel()
+
+	defaultScoop := scoop.NewCustomScoop(t.TempDir())
+	projectDir := t.TempDir()
+	unused := filepath.Join(projectDir, ".scoop")
+	recent := filepath.Join(t.TempDir(), "recent")
+	missing := filepath.Join(t.TempDir(), "missing")
+	require.NoError(t, os.MkdirAll(unused, os.ModePerm))
+	require.NoError(t, os.MkdirAll(recent, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "shell.bat"), nil, 0o600))
+
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+	require.NoError(t, saveShellRegistry(defaultScoop, []shellRegistration{
+		{Root: unused, Created: longAgo, LastUsed: longAgo},
+		{Root: recent, Created: longAgo, LastUsed: time.Now()},
+		{Root: missing, Created: longAgo, LastUsed: longAgo},
+	}))
+	roots := func() []string {
+		registrations, err := loadShellRegistry(defaultScoop)
+		require.NoError(t, err)
+		var roots []string
+		for _, registration := range registrations {
+			roots = append(roots, registration.Root)
+		}
+		return roots
+	}
+
+	// Without a max age, unused environments are kept, as they might still be
+	// entered via their activation scripts.
+	require.NoError(t, collectShellGarbage(defaultScoop, 0))
+	require.Equal(t, []string{unused, recent}, roots())
+	require.DirExists(t, unused)
+
+	require.NoError(t, collectShellGarbage(defaultScoop, 30*24*time.Hour))
+	require.Equal(t, []string{recent}, roots())
+	require.NoDirExists(t, unused)
+	require.NoFileExists(t, filepath.Join(projectDir, "shell.bat"))
+	require.DirExists(t, recent)
+}
+
+func Test_DeleteShellEnvironment_KeepsLinkedDirs(t *testing.T) {
+	t.Parallel()
+
+	target := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(target, "file"), []byte("content"), 0o644))
+	require.NoError(t, os.Chmod(target, 0o755))
+	targetInfo, err := os.Stat(target)
+	require.NoError(t, err)
+
+	root := filepath.Join(t.TempDir(), ".scoop")
+	require.NoError(t, os.MkdirAll(root, os.ModePerm))
+	require.NoError(t, windows.DefaultLinker{}.LinkDir(target, filepath.Join(root, "cache")))
+
+	require.NoError(t, deleteShellEnvironment(root))
+	require.NoDirExists(t, root)
+
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	require.Equal(t, targetInfo.Mode(), info.Mode())
+	require.FileExists(t, filepath.Join(target, "file"))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+func shellRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [app...] -- {command} [args...]",
+		Short: "Run a single command in a temporary environment",
+		Long: strings.TrimSpace(`
+Run a single command in a temporary environment containing the given apps.
+
+If no apps are passed, the spoon.json in the current directory is used. The
+environment is deleted after the command finishes, unless --keep is passed. The
+exit code of the command is passed through.`),
+		Example: cli.FormatUsageExample(
+			"spoon shell run golangci-lint -- golangci-lint run ./...",
+			"spoon shell run -- go test ./...",
+		),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dash := cmd.ArgsLenAtDash(); dash == -1 || dash == len(args) {
+				return errors.New("no command passed, pass it after '--'")
+			}
+			return nil
+		},
+		ValidArgsFunction: autocompleteAvailable,
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			apps, command := args[:dash], args[dash:]
+
+			var (
+				extraEnv    map[string]string
+				projectHash string
+			)
+			if len(apps) == 0 {
+				project, hash, err := readWorkDirProjectFile()
+				if err != nil {
+					return err
+				}
+				apps, extraEnv, projectHash = project.Apps, project.Env, hash
+			}
+
+			keep := must(cmd.Flags().GetBool("keep"))
+			var (
+				root string
+				err  error
+			)
+			if keep {
+				root, err = filepath.Abs("./.scoop")
+				if err != nil {
+					return fmt.Errorf("error getting abs scoop path: %w", err)
+				}
+			} else {
+				root, err = os.MkdirTemp("", "spoon-shell-")
+				if err != nil {
+					return fmt.Errorf("error creating temporary scoop dir: %w", err)
+				}
+			}
+
+			exitCode, err := runInShell(root, apps, extraEnv, projectHash, command)
+			if !keep {
+				if err := removeTemporaryShell(root); err != nil {
+					fmt.Println(err)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().Bool("keep", false, "Use and keep the environment in the .scoop dir of the current directory, instead of a temporary one")
+
+	return cmd
+}
+
+// runInShell sets up a shell scoop at the given root and runs the command
+// with the environment of the shell applied. The exit code of the command is
+// returned.
+func runInShell(
+	root string,
+	apps []string,
+	extraEnv map[string]string,
+	projectHash string,
+	command []string,
+) (int, error) {
+	shellScoop, err := prepareShellScoop(root, apps, projectHash)
+	if err != nil {
+		return 0, err
+	}
+
+	pathAdditions, env, err := shellEnvironment(shellScoop, apps, extraEnv)
+	if err != nil {
+		return 0, err
+	}
+
+	executable, err := lookPathIn(command[0], pathAdditions)
+	if err != nil {
+		return 0, fmt.Errorf("error finding command: %w", err)
+	}
+
+	child := exec.Command(executable, command[1:]...)
+	child.Env = childEnvironment(os.Environ(), pathAdditions, env)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// The child handles interrupts itself, while we keep running, so that we
+	// can clean up afterwards.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("error running command: %w", err)
+	}
+	return 0, nil
+}
+
+// lookPathIn looks up the executable in the given directories first, falling
+// back to the PATH of the current process. This is required, as
+// [exec.Command] only respects the PATH of the current process.
+func lookPathIn(file string, dirs []string) (string, error) {
+	if strings.ContainsAny(file, `/\`) {
+		return exec.LookPath(file)
+	}
+
+	for _, dir := range dirs {
+		// Passing a path causes only that path to be checked, but
+		// including the extensions in PATHEXT.
+		if path, err := exec.LookPath(filepath.Join(dir, file)); err == nil {
+			return path, nil
+		}
+	}
+	return exec.LookPath(file)
+}
+
+// childEnvironment applies the shell environment to the given environment in
+// the form of KEY=value. Keys are compared case insensitively, as Windows does.
+func childEnvironment(environ []string, pathAdditions []string, env []scoop.EnvVar) []string {
+	overridden := make(map[string]bool, len(env)+1)
+	for _, envVar := range env {
+		overridden[strings.ToUpper(envVar.Key)] = true
+	}
+	overridden["PATH"] = true
+
+	var oldPath string
+	result := make([]string, 0, len(environ)+len(env))
+	for _, entry := range environ {
+		// Windows has hidden variables starting with '=', such as `=C:`.
+		separator := strings.IndexByte(entry[min(1, len(entry)):], '=')
+		if separator == -1 {
+			result = append(result, entry)
+			continue
+		}
+		key, value := entry[:separator+1], entry[separator+2:]
+		if strings.EqualFold(key, "PATH") {
+			oldPath = value
+		}
+		if !overridden[strings.ToUpper(key)] {
+			result = append(result, entry)
+		}
+	}
+
+	for _, envVar := range env {
+		result = append(result, envVar.Key+"="+envVar.Value)
+	}
+	newPath := strings.Join(pathAdditions, string(os.PathListSeparator))
+	if oldPath != "" {
+		newPath += string(os.PathListSeparator) + oldPath
+	}
+	return append(result, "PATH="+newPath)
+}
+
+// removeTemporaryShell deletes and unregisters the environment. If this
+// doesn't happen due to a crash, `spoon shell gc` takes care of it.
+func removeTemporaryShell(root string) error {
+	if err := windows.ForceRemoveAll(root); err != nil {
+		return fmt.Errorf("error deleting temporary environment '%s': %w", root, err)
+	}
+
+	defaultScoop, err := scoop.NewScoop()
+	if err != nil {
+		return fmt.Errorf("error getting default scoop: %w", err)
+	}
+	return unregisterShell(defaultScoop, root)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ChildEnvironment(t *testing.T) {
+	t.Parallel()
+
+	separator := string(os.PathListSeparator)
+	tests := []struct {
+		name          string
+		environ       []string
+		pathAdditions []string
+		env           []scoop.EnvVar
+		expected      []string
+	}{
+		{
+			name:          "path prepended",
+			environ:       []string{"HOME=/home/me", "PATH=/usr/bin"},
+			pathAdditions: []string{"/a", "/b"},
+			expected:      []string{"HOME=/home/me", "PATH=/a" + separator + "/b" + separator + "/usr/bin"},
+		},
+		{
+			name:          "no previous path",
+			environ:       []string{"HOME=/home/me"},
+			pathAdditions: []string{"/a"},
+			expected:      []string{"HOME=/home/me", "PATH=/a"},
+		},
+		{
+			name:          "keys case insensitive",
+			environ:       []string{"Path=/usr/bin", "GoPath=/old", "other=x"},
+			pathAdditions: []string{"/a"},
+			env:           []scoop.EnvVar{{Key: "GOPATH", Value: "/new"}},
+			expected:      []string{"other=x", "GOPATH=/new", "PATH=/a" + separator + "/usr/bin"},
+		},
+		{
+			name:          "hidden and malformed entries kept",
+			environ:       []string{`=C:=C:\work`, "MALFORMED", "EMPTY="},
+			pathAdditions: []string{"/a"},
+			env:           []scoop.EnvVar{{Key: "EMPTY", Value: "set"}},
+			expected:      []string{`=C:=C:\work`, "MALFORMED", "EMPTY=set", "PATH=/a"},
+		},
+		{
+			name:          "values containing equals",
+			environ:       []string{"FLAGS=-a=b", "PATH=/usr/bin"},
+			pathAdditions: nil,
+			expected:      []string{"FLAGS=-a=b", "PATH=" + separator + "/usr/bin"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, childEnvironment(test.environ, test.pathAdditions, test.env))
+		})
+	}
+}
+
+func Test_LookPathIn(t *testing.T) {
+	t.Parallel()
+
+	// On Windows, the extension is resolved via PATHEXT, while other systems
+	// require the executable bit.
+	fileName := "spoon-test-tool"
+	if runtime.GOOS == "windows" {
+		fileName += ".exe"
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fileName), nil, 0o700))
+	}
+	onlySecond := "spoon-test-other"
+	if runtime.GOOS == "windows" {
+		onlySecond += ".cmd"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(second, onlySecond), nil, 0o700))
+
+	tests := []struct {
+		name     string
+		file     string
+		dirs     []string
+		expected string
+	}{
+		{
+			name:     "first dir takes precedence",
+			file:     "spoon-test-tool",
+			dirs:     []string{first, second},
+			expected: filepath.Join(first, fileName),
+		},
+		{
+			name:     "later dir",
+			file:     "spoon-test-other",
+			dirs:     []string{first, second},
+			expected: filepath.Join(second, onlySecond),
+		},
+		{
+			name:     "paths are used as is",
+			file:     filepath.Join(second, fileName),
+			dirs:     []string{first},
+			expected: filepath.Join(second, fileName),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := lookPathIn(test.file, test.dirs)
+			require.NoError(t, err)
+			require.True(t, strings.EqualFold(test.expected, path), "expected %s, got %s", test.expected, path)
+		})
+	}
+
+	_, err := lookPathIn("spoon-test-missing", []string{first, second})
+	require.Error(t, err)
+}
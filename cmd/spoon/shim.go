@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+type shimEntry struct {
+	Name   string `json:"name"`
+	App    string `json:"app,omitempty"`
+	Target string `json:"target"`
+	Args   string `json:"args,omitempty"`
+	User   bool   `json:"user"`
+	Broken bool   `json:"broken"`
+}
+
+func shimCmd() *cobra.Command {
+	shimRoot := &cobra.Command{
+		Use:   "shim",
+		Short: "Allows managing shims",
+		Long:  "Allows listing, adding, removing and altering shims. Shims are small executables in the shim dir, that forward to the actual executable of an app.",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list [pattern]",
+		Short: "List all shims",
+		Long:  "List all shims. The optional pattern is a case insensitive regular expression matched against the shim name.",
+		Example: cli.FormatUsageExample(
+			"spoon shim list",
+			"spoon shim list ^go",
+			"spoon shim list --out-format json",
+		),
+		Args: cobra.MaximumNArgs(1),
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			var pattern *regexp.Regexp
+			if len(args) > 0 {
+				var err error
+				pattern, err = regexp.Compile("(?i)" + args[0])
+				if err != nil {
+					return fmt.Errorf("error parsing pattern: %w", err)
+				}
+			}
+
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			shims, err := defaultScoop.Shims()
+			if err != nil {
+				return fmt.Errorf("error getting shims: %w", err)
+			}
+			registry, err := defaultScoop.LoadShimRegistry()
+			if err != nil {
+				return err
+			}
+
+			entries := make([]shimEntry, 0, len(shims))
+			for _, shim := range shims {
+				if pattern != nil && !pattern.MatchString(shim.Name) {
+					continue
+				}
+
+				appName, _, err := defaultScoop.FindOwningApp(shim.Target)
+				if err != nil {
+					return fmt.Errorf("error determining owning app: %w", err)
+				}
+				_, statErr := os.Stat(shim.Target)
+				registration := registry.Get(shim.Name)
+				entries = append(entries, shimEntry{
+					Name:   shim.Name,
+					App:    appName,
+					Target: shim.Target,
+					Args:   shim.Args,
+					User:   registration != nil && registration.User,
+					Broken: os.IsNotExist(statErr),
+				})
+			}
+
+			switch must(cmd.Flags().GetString("out-format")) {
+			case "json":
+				if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+					return fmt.Errorf("error encoding shims: %w", err)
+				}
+			case "plain":
+				tbl, _, _ := cli.CreateTable("Name", "App", "Target", "Args", "Info")
+				for _, entry := range entries {
+					var info []string
+					if entry.User {
+						info = append(info, "User defined")
+					}
+					if entry.Broken {
+						info = append(info, "Target missing")
+					}
+					tbl.AddRow(
+						entry.Name,
+						entry.App,
+						entry.Target,
+						entry.Args,
+						strings.Join(info, ","),
+					)
+				}
+
+				fmt.Print("\n")
+				tbl.Print()
+				fmt.Print("\n")
+			default:
+				return fmt.Errorf("unsupported output format")
+			}
+
+			return nil
+		}),
+	}
+	listCmd.Flags().String("out-format", "plain", "Specifies the output format to use for any data printed")
+	listCmd.RegisterFlagCompletionFunc("out-format", cobra.FixedCompletions(
+		[]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	shimRoot.AddCommand(
+		listCmd,
+		&cobra.Command{
+			Use:   "add {name} {path} [args...]",
+			Short: "Add a shim for an arbitrary file",
+			Long: strings.TrimSpace(`
+Add a shim for an arbitrary file. Existing shims with the same name are replaced.
+
+Shims added manually are kept when installing or resetting apps that provide a
+shim with the same name.`),
+			Example: cli.FormatUsageExample(
+				"spoon shim add mytool C:/tools/mytool.exe",
+				"spoon shim add serve C:/tools/server.cmd -- --port 8080",
+			),
+			Args: cobra.MinimumNArgs(2),
+			RunE: RunE(func(cmd *cobra.Command, args []string) error {
+				defaultScoop, err := scoop.NewScoop()
+				if err != nil {
+					return fmt.Errorf("error getting default scoop: %w", err)
+				}
+
+				path, err := filepath.Abs(args[1])
+				if err != nil {
+					return fmt.Errorf("error resolving path: %w", err)
+				}
+				if _, err := os.Stat(path); err != nil {
+					return fmt.Errorf("error checking target: %w", err)
+				}
+
+				if err := defaultScoop.AddUserShim(args[0], path, args[2:]...); err != nil {
+					return fmt.Errorf("error adding shim: %w", err)
+				}
+				fmt.Printf("Added shim '%s' for '%s'\n", args[0], path)
+				return nil
+			}),
+		},
+		&cobra.Command{
+			Use: "rm {name...}",
+			Aliases: []string{
+				"remove",
+				"delete",
+			},
+			Short: "Remove shims",
+			Long:  "Remove shims. Note that shims belonging to an app are recreated when resetting or updating the app.",
+			Example: cli.FormatUsageExample(
+				"spoon shim rm mytool",
+			),
+			Args:              cobra.MinimumNArgs(1),
+			ValidArgsFunction: autocompleteShims,
+			RunE: RunE(func(cmd *cobra.Command, args []string) error {
+				defaultScoop, err := scoop.NewScoop()
+				if err != nil {
+					return fmt.Errorf("error getting default scoop: %w", err)
+				}
+
+				var failed bool
+				for _, name := range args {
+					if err := defaultScoop.RemoveShim(name); err != nil {
+						if errors.Is(err, scoop.ErrShimNotFound) {
+							fmt.Printf("Shim '%s' doesn't exist\n", name)
+						} else {
+							fmt.Printf("Error removing shim '%s': %s\n", name, err)
+						}
+						failed = true
+						continue
+					}
+					fmt.Printf("Removed shim '%s'\n", name)
+				}
+
+				if failed {
+					return errors.New("not all shims could be removed")
+				}
+				return nil
+			}),
+		},
+		&cobra.Command{
+			Use:   "alter {name} [app]",
+			Short: "Switch a shim to a different app providing it",
+			Long: strings.TrimSpace(`
+Switch a shim to a different app providing it.
+
+If multiple installed apps provide a shim with the same name, only one of them
+can be active. If no app is passed and exactly one other app provides the shim,
+it is switched to that app. Otherwise all providing apps are listed.`),
+			Example: cli.FormatUsageExample(
+				"spoon shim alter python python311",
+			),
+			Args: cobra.RangeArgs(1, 2),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				if len(args) == 0 {
+					return autocompleteShims(cmd, args, toComplete)
+				}
+				return autocompleteInstalled(cmd, args, toComplete)
+			},
+			RunE: RunE(func(cmd *cobra.Command, args []string) error {
+				defaultScoop, err := scoop.NewScoop()
+				if err != nil {
+					return fmt.Errorf("error getting default scoop: %w", err)
+				}
+
+				name := args[0]
+				if len(args) == 2 {
+					if err := defaultScoop.AlterShim(name, args[1]); err != nil {
+						return fmt.Errorf("error altering shim: %w", err)
+					}
+					fmt.Printf("Shim '%s' now points to '%s'\n", name, args[1])
+					return nil
+				}
+
+				providers, err := defaultScoop.ShimProviders(name)
+				if err != nil {
+					return fmt.Errorf("error determining apps providing shim: %w", err)
+				}
+				if len(providers) == 0 {
+					return fmt.Errorf("no installed app provides shim '%s'", name)
+				}
+
+				var currentApp string
+				shim, err := defaultScoop.FindShim(name)
+				if err != nil {
+					return fmt.Errorf("error looking up shim: %w", err)
+				}
+				if shim != nil {
+					currentApp, _, err = defaultScoop.FindOwningApp(shim.Target)
+					if err != nil {
+						return fmt.Errorf("error determining owning app: %w", err)
+					}
+				}
+
+				var candidates []string
+				for _, provider := range providers {
+					if !strings.EqualFold(provider.App.Name, currentApp) {
+						candidates = append(candidates, provider.App.Name)
+					}
+				}
+				if len(candidates) == 1 {
+					if err := defaultScoop.AlterShim(name, candidates[0]); err != nil {
+						return fmt.Errorf("error altering shim: %w", err)
+					}
+					fmt.Printf("Shim '%s' now points to '%s'\n", name, candidates[0])
+					return nil
+				}
+
+				fmt.Printf("Shim '%s' is provided by:\n", name)
+				for _, provider := range providers {
+					if strings.EqualFold(provider.App.Name, currentApp) {
+						fmt.Printf("  %s (active)\n", provider.App.Name)
+					} else {
+						fmt.Printf("  %s\n", provider.App.Name)
+					}
+				}
+				return errors.New("please specify the app to switch to")
+			}),
+		},
+	)
+
+	return shimRoot
+}
@@ -27,7 +27,14 @@ func status(scoop *scoop.Scoop) error {
 			info = append(info, "Manifest removed")
 		}
 		if app.Hold {
-			info = append(info, "Held package")
+			if app.HoldOptions.Reason != "" {
+				info = append(info, fmt.Sprintf("Held package (%s)", app.HoldOptions.Reason))
+			} else {
+				info = append(info, "Held package")
+			}
+			if app.HoldOptions.Expired(app.LatestVersion) {
+				info = append(info, "Hold expired")
+			}
 		}
 		tbl.AddRow(app.Name, app.Version, app.LatestVersion, "", strings.Join(info, ","))
 	}
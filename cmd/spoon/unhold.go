@@ -4,23 +4,38 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/spf13/cobra"
 )
 
 func unholdCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "unhold",
-		Short:              "Unhold an app to disable updates",
-		Args:               cobra.MinimumNArgs(1),
-		ValidArgsFunction:  autocompleteInstalled,
-		DisableFlagParsing: true,
+		Use:               "unhold",
+		Short:             "Unhold an app to enable updates",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: autocompleteInstalled,
 		RunE: RunE(func(cmd *cobra.Command, args []string) error {
-			flags, err := getFlags(cmd, "global")
+			if must(cmd.Flags().GetBool("global")) {
+				flags, err := getFlags(cmd, "global")
+				if err != nil {
+					return fmt.Errorf("error getting flags: %w", err)
+				}
+
+				os.Exit(execScoopCommand("unhold", append(flags, args...)...))
+			}
+
+			defaultScoop, err := scoop.NewScoop()
 			if err != nil {
-				return fmt.Errorf("error getting flags: %w", err)
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			for _, arg := range args {
+				if err := defaultScoop.Unhold(arg); err != nil {
+					return fmt.Errorf("error unholding '%s': %w", arg, err)
+				}
+				fmt.Printf("'%s' is no longer held and can be updated again.\n", arg)
 			}
 
-			os.Exit(execScoopCommand("unhold", append(flags, args...)...))
 			return nil
 		}),
 	}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,6 +28,10 @@ func uninstallCmd() *cobra.Command {
 			// redirectedFlags, err := getFlags(cmd, "global", "purge")
 			// Flags we currently do not support
 			if must(cmd.Flags().GetBool("global")) || !must(cmd.Flags().GetBool("experimental")) {
+				if cmd.Flags().Changed("backup") {
+					return errors.New("--backup is only supported in combination with --experimental")
+				}
+
 				redirectedFlags, err := getFlags(cmd, "global", "purge")
 				if err != nil {
 					fmt.Println(err)
@@ -40,11 +44,23 @@ func uninstallCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("error getting yes flag: %w", err)
 			}
+			purge := must(cmd.Flags().GetBool("purge"))
+			backupDir := must(cmd.Flags().GetString("backup"))
+			if backupDir != "" && !purge {
+				return errors.New("--backup can only be used in combination with --purge")
+			}
 			defaultScoop, err := scoop.NewScoop()
 			if err != nil {
 				return fmt.Errorf("error getting default scoop: %w", err)
 			}
 
+			// We ask once for all apps, instead of interrupting the
+			// uninstallation for each app.
+			if purge && backupDir == "" && !yes &&
+				!askYesNo("All persisted data (such as configuration) of the given apps will be deleted. Continue?") {
+				return nil
+			}
+
 			if err := checkRunningProcesses(defaultScoop, args, yes); err != nil {
 				return fmt.Errorf("error checking running processes: %w", err)
 			}
@@ -53,7 +69,7 @@ func uninstallCmd() *cobra.Command {
 			// InstalledApps. The later returns all of them, returning
 			// everything instead of finding something.
 			for _, arg := range args {
-				app, err := defaultScoop.FindInstalledApp(args[0])
+				app, err := defaultScoop.FindInstalledApp(arg)
 				if err != nil {
 					return err
 				}
@@ -80,6 +96,23 @@ func uninstallCmd() *cobra.Command {
 				if err := windows.ForceRemoveAll(filepath.Join(defaultScoop.AppDir(), app.Name)); err != nil {
 					return fmt.Errorf("error cleaning up installation of '%s': %w", arg, err)
 				}
+
+				if !purge {
+					continue
+				}
+
+				if backupDir != "" {
+					archivePath, err := defaultScoop.BackupPersistData(app.Name, backupDir)
+					if err != nil {
+						return fmt.Errorf("error backing up persisted data of '%s': %w", arg, err)
+					}
+					if archivePath != "" {
+						fmt.Printf("Backed up persisted data of '%s' to '%s'\n", arg, archivePath)
+					}
+				}
+				if err := defaultScoop.PurgePersistData(app.Name); err != nil {
+					return fmt.Errorf("error purging persisted data of '%s': %w", arg, err)
+				}
 			}
 
 			return nil
@@ -88,6 +121,7 @@ func uninstallCmd() *cobra.Command {
 
 	cmd.Flags().BoolP("global", "g", false, "Uninstall a globally installed app")
 	cmd.Flags().BoolP("purge", "p", false, "Remove all persistent data")
+	cmd.Flags().String("backup", "", "Archive persistent data into the given directory before purging")
 	cmd.Flags().BoolP("yes", "y", false, "Decides whether questions arise or are automatically answered")
 
 	return cmd
@@ -130,19 +164,7 @@ PROCESS_LOOP:
 
 		processTable.Print()
 
-		kill := yes
-		if !kill {
-			fmt.Print("Attempt to terminate the processes?\n\r(yes/no)? ")
-			if scanner := bufio.NewScanner(os.Stdin); scanner.Scan() {
-				text := strings.ToLower(scanner.Text())
-				if text == "yes" || text == "y" {
-					kill = true
-				}
-				fmt.Print("\n")
-			}
-		}
-
-		if kill {
+		if yes || askYesNo("Attempt to terminate the processes?") {
 			for _, process := range processesToKill {
 				bool, err := windows.ProcessKill(uint32(process.Pid))
 				if err != nil {
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -68,3 +72,57 @@ func contains(haystack, needle string, caseInsensitive bool) bool {
 
 	return strings.Contains(haystack, needle)
 }
+
+// askYesNo prints the question and reads the answer from stdin. Anything other
+// than "yes" or "y" is treated as "no".
+func askYesNo(question string) bool {
+	fmt.Print(question + "\n\r(yes/no)? ")
+	defer fmt.Print("\n")
+	if scanner := bufio.NewScanner(os.Stdin); scanner.Scan() {
+		text := strings.ToLower(scanner.Text())
+		return text == "yes" || text == "y"
+	}
+	return false
+}
+
+// sortByFields sorts the values according to the passed fields in the given
+// order. The earlier in the array the field, the higher the weight during
+// sorting. Fields without a comparator are ignored.
+func sortByFields[T any](values []T, sortFields []string, comparators map[string]func(a, b T) int) {
+	var compareFns []func(a, b T) int
+	for _, sortField := range sortFields {
+		if compareFn, ok := comparators[sortField]; ok {
+			compareFns = append(compareFns, compareFn)
+		}
+	}
+
+	if len(compareFns) > 0 {
+		slices.SortStableFunc(values, func(a, b T) int {
+			for _, compareFn := range compareFns {
+				if result := compareFn(a, b); result != 0 {
+					return result
+				}
+			}
+			return 0
+		})
+	}
+}
+
+// printKeyValues prints one aligned "key : value" line per row. Rows with an
+// empty value are skipped.
+func printKeyValues(rows [][2]string) {
+	var keyWidth int
+	for _, row := range rows {
+		keyWidth = max(keyWidth, len(row[0]))
+	}
+	keyColor := color.New(color.FgGreen, color.Bold)
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+
+		// Multiline values, such as notes, are indented to the value column.
+		value := strings.ReplaceAll(row[1], "\n", "\n"+strings.Repeat(" ", keyWidth+3))
+		fmt.Printf("%s : %s\n", keyColor.Sprintf("%-*s", keyWidth, row[0]), value)
+	}
+}
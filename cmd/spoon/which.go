@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/cli"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/spf13/cobra"
+)
+
+func whichCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "which {command | path}",
+		Short: "Locate the executable behind a command and the app owning it",
+		Long: strings.TrimSpace(`
+Locate the executable behind a command and the app owning it.
+
+If a command is passed, its shim is resolved to the actual executable. If a path
+is passed, the app and version owning the path are determined.`),
+		Example: cli.FormatUsageExample(
+			"spoon which go",
+			"spoon which C:/Users/me/scoop/apps/go/1.22.1/bin/go.exe",
+		),
+		Args: cobra.ExactArgs(1),
+		RunE: RunE(func(cmd *cobra.Command, args []string) error {
+			defaultScoop, err := scoop.NewScoop()
+			if err != nil {
+				return fmt.Errorf("error getting default scoop: %w", err)
+			}
+
+			// Commands can't contain path separators, so anything containing
+			// one, or anything that exists, is treated as a path.
+			var path string
+			if strings.ContainsAny(args[0], `/\`) {
+				path = args[0]
+			} else if _, err := os.Stat(args[0]); err == nil {
+				path = args[0]
+			}
+
+			var rows [][2]string
+			if path == "" {
+				shim, err := defaultScoop.FindShim(args[0])
+				if err != nil {
+					return fmt.Errorf("error looking up shim: %w", err)
+				}
+
+				if shim != nil {
+					path = shim.Target
+					rows = append(rows,
+						[2]string{"Shim", shim.Path},
+						[2]string{"Target", shim.Target},
+						[2]string{"Arguments", shim.Args},
+					)
+				} else {
+					// The command might still be available via env_add_path.
+					path, err = exec.LookPath(args[0])
+					if err != nil {
+						if errors.Is(err, exec.ErrNotFound) {
+							return fmt.Errorf("command '%s' not found", args[0])
+						}
+						return fmt.Errorf("error looking up command: %w", err)
+					}
+					rows = append(rows, [2]string{"Path", path})
+				}
+			} else {
+				rows = append(rows, [2]string{"Path", path})
+			}
+
+			appName, version, err := defaultScoop.FindOwningApp(path)
+			if err != nil {
+				return fmt.Errorf("error determining owning app: %w", err)
+			}
+			if appName == "" {
+				rows = append(rows, [2]string{"App", "Not installed via scoop"})
+			} else {
+				rows = append(rows,
+					[2]string{"App", appName},
+					[2]string{"Version", version},
+				)
+			}
+
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				rows = append(rows, [2]string{"Warning", "Target doesn't exist"})
+			}
+
+			printKeyValues(rows)
+			return nil
+		}),
+	}
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+// Package scooptest provides helpers for tests installing apps from a local
+// bucket, without requiring network access or a scoop installation.
+package scooptest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+// Archive is a zip archive served via HTTP for the duration of a test.
+type Archive struct {
+	// URL is the base URL of the server. Any path is answered with the
+	// archive, except for paths ending in "missing.zip", which are answered
+	// with a 404, to simulate failing downloads.
+	URL string
+	// Hash is the hex encoded SHA256 hash of the archive.
+	Hash string
+}
+
+// CreateZip creates an archive containing the given files. The keys are
+// slash separated paths.
+func CreateZip(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buffer.Bytes()
+}
+
+// ServeZip serves an archive created via [CreateZip] until the test ends.
+func ServeZip(t testing.TB, files map[string]string) Archive {
+	t.Helper()
+
+	archive := CreateZip(t, files)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "missing.zip") {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = writer.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+
+	hash := sha256.Sum256(archive)
+	return Archive{URL: server.URL, Hash: hex.EncodeToString(hash[:])}
+}
+
+// WriteManifest writes the manifest for the given app into the main bucket,
+// overwriting previous versions.
+func WriteManifest(t testing.TB, customScoop *scoop.Scoop, app, manifest string) {
+	t.Helper()
+
+	manifestDir := filepath.Join(customScoop.BucketDir(), "main", "bucket")
+	require.NoError(t, os.MkdirAll(manifestDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(manifestDir, app+".json"), []byte(manifest), 0o600))
+}
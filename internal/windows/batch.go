@@ -0,0 +1,27 @@
+package windows
+
+import "strings"
+
+// EscapeBatch escapes a value for use in a batch file line. Variable expansion
+// is prevented everywhere, while characters with a special meaning, such as
+// `&` or `)`, are escaped using carets wherever cmd considers them to be
+// outside of quotes. Since cmd has no concept of escaped quotes, each quote
+// in the value toggles that state. inQuotes defines whether the value is
+// placed inside of double quotes.
+func EscapeBatch(value string, inQuotes bool) string {
+	var escaped strings.Builder
+	for _, char := range value {
+		switch char {
+		case '"':
+			inQuotes = !inQuotes
+		case '%':
+			escaped.WriteByte('%')
+		case '&', '|', '<', '>', '^', '(', ')':
+			if !inQuotes {
+				escaped.WriteByte('^')
+			}
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
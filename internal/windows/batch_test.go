@@ -0,0 +1,55 @@
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EscapeBatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    string
+		inQuotes bool
+		expected string
+	}{
+		{
+			name:     "plain",
+			value:    `C:\Program Files\app`,
+			inQuotes: true,
+			expected: `C:\Program Files\app`,
+		},
+		{
+			name:     "percent",
+			value:    `100%PATH%`,
+			inQuotes: true,
+			expected: `100%%PATH%%`,
+		},
+		{
+			name:     "specials in quotes",
+			value:    `a&b|c<d>e^f(g)`,
+			inQuotes: true,
+			expected: `a&b|c<d>e^f(g)`,
+		},
+		{
+			name:     "specials outside quotes",
+			value:    `a&b|c<d>e^f(g)`,
+			expected: `a^&b^|c^<d^>e^^f^(g^)`,
+		},
+		{
+			name:     "quote ends quoted section",
+			value:    `a"&b"&c`,
+			inQuotes: true,
+			expected: `a"^&b"&c`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, windows.EscapeBatch(test.value, test.inQuotes))
+		})
+	}
+}
@@ -2,10 +2,7 @@ package windows
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
 	"os"
-	"os/exec"
 	"slices"
 	"strings"
 )
@@ -50,26 +47,97 @@ func ParsePath(value string) Paths {
 	return Paths(values)
 }
 
+// Contains checks whether the given path is part of the paths, comparing
+// normalised paths.
+func (p Paths) Contains(path string) bool {
+	normalised := NormalisePath(path)
+	return slices.ContainsFunc(p, func(value string) bool {
+		return NormalisePath(value) == normalised
+	})
+}
+
 // Remove returns a new path object that doesn't contain any of the specified
-// paths.
+// paths. Paths are compared normalised, see [NormalisePath].
 func (p Paths) Remove(paths ...string) Paths {
-	p = slices.DeleteFunc(p, func(value string) bool {
-		// FIXME This should sanitize the path separators and such. We also need
-		// tests for this.
-		return slices.Contains(paths, value)
+	toRemove := make([]string, len(paths))
+	for index, path := range paths {
+		toRemove[index] = NormalisePath(path)
+	}
+	return slices.DeleteFunc(slices.Clone(p), func(value string) bool {
+		return slices.Contains(toRemove, NormalisePath(value))
 	})
-	return p
 }
 
 // Preprend will create a new Paths object, adding the supplied paths infront,
-// using the given order.
+// using the given order. Existing entries are moved to the front instead of
+// being duplicated.
 func (p Paths) Prepend(paths ...string) Paths {
 	newPath := make(Paths, 0, len(p)+len(paths))
 	newPath = append(newPath, paths...)
-	newPath = append(newPath, p...)
+	newPath = append(newPath, p.Remove(paths...)...)
+	return newPath.Deduplicate()
+}
+
+// Deduplicate returns a new path object, where only the first occurrence of
+// each path is kept. Paths are compared normalised, see [NormalisePath].
+func (p Paths) Deduplicate() Paths {
+	seen := make(map[string]bool, len(p))
+	newPath := make(Paths, 0, len(p))
+	for _, value := range p {
+		normalised := NormalisePath(value)
+		if seen[normalised] {
+			continue
+		}
+		seen[normalised] = true
+		newPath = append(newPath, value)
+	}
 	return newPath
 }
 
+// NormalisePath turns the path into a form that can be compared to other
+// paths. Environment variables, such as %USERPROFILE%, are expanded, casing
+// is ignored, forward slashes are treated as backslashes and trailing
+// separators are removed. The result isn't meant to be written back.
+func NormalisePath(path string) string {
+	path = expandEnvVars(path)
+	path = strings.ReplaceAll(path, "/", `\`)
+	// Keep the separator of drive roots, such as `C:\`.
+	trimmed := strings.TrimRight(path, `\`)
+	if len(trimmed) == 2 && trimmed[1] == ':' {
+		trimmed += `\`
+	}
+	return strings.ToLower(trimmed)
+}
+
+// expandEnvVars expands variables in the windows format of %NAME%. Unknown
+// variables are kept, just like windows does.
+func expandEnvVars(value string) string {
+	var result strings.Builder
+	for {
+		start := strings.IndexByte(value, '%')
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(value[start+1:], '%')
+		if end == -1 {
+			break
+		}
+		end += start + 1
+
+		result.WriteString(value[:start])
+		if expanded, ok := os.LookupEnv(value[start+1 : end]); ok && end > start+1 {
+			result.WriteString(expanded)
+			value = value[end+1:]
+		} else {
+			// The closing percent might be the start of the next variable.
+			result.WriteString(value[start:end])
+			value = value[end:]
+		}
+	}
+	result.WriteString(value)
+	return result.String()
+}
+
 // Creates a new path string, where all entries are quoted.
 func (p Paths) String() string {
 	var buffer bytes.Buffer
@@ -85,115 +153,3 @@ func (p Paths) String() string {
 	}
 	return buffer.String()
 }
-
-func GetPersistentEnvValues() (map[string]string, error) {
-	cmd := exec.Command(
-		"powershell",
-		"-NoLogo",
-		"-NoProfile",
-		"[Environment]::GetEnvironmentVariables('User') | ConvertTo-Json",
-	)
-	pipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("error opening pipe: %w", err)
-	}
-
-	var cmdErr error
-	go func() {
-		cmdErr = cmd.Run()
-	}()
-
-	decoder := json.NewDecoder(pipe)
-	result := make(map[string]string)
-	if err := decoder.Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding environment variables: %w", err)
-	}
-
-	if cmdErr != nil {
-		return nil, fmt.Errorf("error retrieving environment variables: %w", err)
-	}
-
-	return result, nil
-}
-
-// GetPersistentEnvValue retrieves a persistent user level environment variable.
-// The first returned value is the key and the second the value. While the key
-// is defined in the query, the casing might be different, which COULD matter.
-// If nothing was found, we return empty strings without an error.
-func GetPersistentEnvValue(key string) (string, string, error) {
-	// While we could directly call GetEnvironmentVariable, we want to find out
-	// he string, therefore we use the result of the GetAll call.
-
-	allVars, err := GetPersistentEnvValues()
-	if err != nil {
-		return "", "", fmt.Errorf("error retrieving variables: %w", err)
-	}
-
-	for keyPersisted, val := range allVars {
-		if strings.EqualFold(key, keyPersisted) {
-			return keyPersisted, val, nil
-		}
-	}
-	return "", "", nil
-}
-
-// Sets a User-Level Environment variable. An empty value will remove the key
-// completly.
-func SetPersistentEnvValue(key, value string) error {
-	cmd := exec.Command(
-		"powershell",
-		"-NoProfile",
-		"-NoLogo",
-		"-Command",
-		"[Environment]::SetEnvironmentVariable('"+key+"','"+value+"','User')",
-	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	return cmd.Run()
-}
-
-func SetPersistentEnvValues(vars ...[2]string) error {
-	if len(vars) == 0 {
-		return nil
-	}
-
-	var command bytes.Buffer
-	for _, pair := range vars {
-		command.WriteString("[Environment]::SetEnvironmentVariable('")
-		command.WriteString(pair[0])
-		command.WriteString("','")
-		command.WriteString(pair[1])
-		command.WriteString("','User');")
-	}
-
-	cmd := exec.Command(
-		"powershell",
-		"-NoProfile",
-		"-NoLogo",
-		"-Command",
-		command.String(),
-	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	return cmd.Run()
-}
-
-// GetFolderPath is equivalent to [Environment]::GetFolderPath and determines
-// user-specific folder locations.
-func GetFolderPath(folderType string) (string, error) {
-	cmd := exec.Command(
-		"powershell",
-		"-NoLogo",
-		"-NoProfile",
-		`[Environment]::GetFolderPath("`+folderType+`")`,
-	)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", err
-	}
-
-	// Trim, since we don't want the newline.
-	return strings.TrimSpace(string(output)), nil
-}
@@ -10,7 +10,264 @@ import (
 func Test_ParsePath(t *testing.T) {
 	t.Parallel()
 
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: nil,
+		},
+		{
+			name:     "single",
+			value:    `C:\path_a`,
+			expected: []string{`C:\path_a`},
+		},
+		{
+			name:     "quoted with separator",
+			value:    `C:\path_a;"C:\path_b";"C:\path_;";C:\path_c`,
+			expected: []string{`C:\path_a`, `C:\path_b`, `C:\path_;`, `C:\path_c`},
+		},
+		{
+			name:     "trailing separator",
+			value:    `C:\path_a;C:\path_b;`,
+			expected: []string{`C:\path_a`, `C:\path_b`},
+		},
+		{
+			name:     "trailing quote",
+			value:    `C:\path_a;"C:\path_b"`,
+			expected: []string{`C:\path_a`, `C:\path_b`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, []string(windows.ParsePath(test.value)))
+		})
+	}
+}
+
+func Test_Paths_String(t *testing.T) {
+	t.Parallel()
+
 	path := windows.ParsePath(`C:\path_a;"C:\path_b";"C:\path_;";C:\path_c`)
-	require.Equal(t, []string{`C:\path_a`, `C:\path_b`, `C:\path_;`, `C:\path_c`}, []string(path))
 	require.Equal(t, `"C:\path_a";"C:\path_b";"C:\path_;";"C:\path_c"`, path.String())
 }
+
+func Test_NormalisePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: `C:\Path`, expected: `c:\path`},
+		{path: `C:/Path/Sub`, expected: `c:\path\sub`},
+		{path: `C:\Path\`, expected: `c:\path`},
+		{path: `C:\Path\\`, expected: `c:\path`},
+		{path: `C:\`, expected: `c:\`},
+		{path: `C:`, expected: `c:\`},
+		{path: `C:/`, expected: `c:\`},
+		{path: `%SPOON_UNKNOWN_VAR%\bin`, expected: `%spoon_unknown_var%\bin`},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, windows.NormalisePath(test.path))
+		})
+	}
+}
+
+func Test_NormalisePath_EnvVars(t *testing.T) {
+	t.Setenv("SPOON_TEST_HOME", `C:\Users\Spoon`)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: `%SPOON_TEST_HOME%\bin`, expected: `c:\users\spoon\bin`},
+		{path: `100%\%SPOON_TEST_HOME%`, expected: `100%\c:\users\spoon`},
+		{path: `%%SPOON_TEST_HOME%`, expected: `%c:\users\spoon`},
+		{path: `%SPOON_TEST_HOME`, expected: `%spoon_test_home`},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require.Equal(t, test.expected, windows.NormalisePath(test.path))
+		})
+	}
+
+	path := windows.Paths{`%SPOON_TEST_HOME%\bin`, `C:\other`}
+	require.True(t, path.Contains(`C:\Users\Spoon\bin\`))
+	require.Equal(t, []string{`C:\other`}, []string(path.Remove(`c:/users/spoon/bin`)))
+}
+
+func Test_Paths_Contains(t *testing.T) {
+	t.Parallel()
+
+	path := windows.Paths{`C:\Path_A`, `C:\path_b\`}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: `C:\Path_A`, expected: true},
+		{path: `c:\path_a`, expected: true},
+		{path: `C:/Path_A/`, expected: true},
+		{path: `C:\path_b`, expected: true},
+		{path: `C:\path_c`, expected: false},
+		{path: `C:\Path_A\sub`, expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, path.Contains(test.path))
+		})
+	}
+}
+
+func Test_Paths_Remove(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     windows.Paths
+		remove   []string
+		expected []string
+	}{
+		{
+			name:     "exact",
+			path:     windows.Paths{`C:\a`, `C:\b`},
+			remove:   []string{`C:\a`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "case",
+			path:     windows.Paths{`C:\Apps\Go\bin`, `C:\b`},
+			remove:   []string{`c:\apps\go\BIN`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "slashes",
+			path:     windows.Paths{`C:\a\bin`, `C:\b`},
+			remove:   []string{`C:/a/bin`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "trailing separator",
+			path:     windows.Paths{`C:\a\`, `C:\b`},
+			remove:   []string{`C:\a`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "all occurrences",
+			path:     windows.Paths{`C:\a`, `C:\b`, `C:\A\`},
+			remove:   []string{`C:\a`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "multiple",
+			path:     windows.Paths{`C:\a`, `C:\b`, `C:\c`},
+			remove:   []string{`C:\a`, `C:\c`},
+			expected: []string{`C:\b`},
+		},
+		{
+			name:     "missing",
+			path:     windows.Paths{`C:\a`},
+			remove:   []string{`C:\b`},
+			expected: []string{`C:\a`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			original := append(windows.Paths(nil), test.path...)
+			require.Equal(t, test.expected, []string(test.path.Remove(test.remove...)))
+			// The receiver must not be modified.
+			require.Equal(t, original, test.path)
+		})
+	}
+}
+
+func Test_Paths_Prepend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     windows.Paths
+		prepend  []string
+		expected []string
+	}{
+		{
+			name:     "new",
+			path:     windows.Paths{`C:\a`},
+			prepend:  []string{`C:\b`, `C:\c`},
+			expected: []string{`C:\b`, `C:\c`, `C:\a`},
+		},
+		{
+			name:     "empty",
+			path:     nil,
+			prepend:  []string{`C:\a`},
+			expected: []string{`C:\a`},
+		},
+		{
+			name:     "move to front",
+			path:     windows.Paths{`C:\a`, `C:\b`},
+			prepend:  []string{`C:\b`},
+			expected: []string{`C:\b`, `C:\a`},
+		},
+		{
+			name:     "move differently written",
+			path:     windows.Paths{`C:\a`, `C:\B\`},
+			prepend:  []string{`c:/b`},
+			expected: []string{`c:/b`, `C:\a`},
+		},
+		{
+			name:     "duplicate arguments",
+			path:     windows.Paths{`C:\a`},
+			prepend:  []string{`C:\b`, `C:\B`},
+			expected: []string{`C:\b`, `C:\a`},
+		},
+		{
+			name:     "repeated",
+			path:     windows.Paths{`C:\b`, `C:\a`},
+			prepend:  []string{`C:\b`},
+			expected: []string{`C:\b`, `C:\a`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, []string(test.path.Prepend(test.prepend...)))
+		})
+	}
+}
+
+func Test_Paths_Deduplicate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     windows.Paths
+		expected []string
+	}{
+		{
+			name:     "unique",
+			path:     windows.Paths{`C:\a`, `C:\b`},
+			expected: []string{`C:\a`, `C:\b`},
+		},
+		{
+			name:     "first occurrence kept",
+			path:     windows.Paths{`C:\a`, `C:\b`, `C:\A\`, `c:/b`},
+			expected: []string{`C:\a`, `C:\b`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, []string(test.path.Deduplicate()))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+//go:build windows
+
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	sys "golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+var (
+	modUser32              = syscall.NewLazyDLL("user32.dll")
+	procSendMessageTimeout = modUser32.NewProc("SendMessageTimeoutW")
+)
+
+// UserEnv is the persistent environment of the current user. It is stored in
+// the registry at HKEY_CURRENT_USER\Environment. Values are read and written
+// raw, so references such as %USERPROFILE% aren't expanded.
+type UserEnv struct{}
+
+const userEnvKey = "Environment"
+
+// Values returns all user variables.
+func (UserEnv) Values() (map[string]string, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, userEnvKey, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, fmt.Errorf("error opening environment key: %w", err)
+	}
+	defer key.Close()
+
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return nil, fmt.Errorf("error reading variable names: %w", err)
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, _, err := key.GetStringValue(name)
+		if err != nil {
+			// Non-string values can't be environment variables.
+			if errors.Is(err, registry.ErrUnexpectedType) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading variable '%s': %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Get retrieves a user variable. The first returned value is the key and the
+// second the value. While the key is defined in the query, the casing might be
+// different, which COULD matter. If nothing was found, we return empty strings
+// without an error.
+func (env UserEnv) Get(key string) (string, string, error) {
+	values, err := env.Values()
+	if err != nil {
+		return "", "", err
+	}
+
+	for storedKey, value := range values {
+		if strings.EqualFold(key, storedKey) {
+			return storedKey, value, nil
+		}
+	}
+	return "", "", nil
+}
+
+// Set sets all given user variables. An empty value removes the variable
+// completely. Afterwards, all windows are notified, so that newly started
+// processes see the changes.
+func (UserEnv) Set(vars map[string]string) error {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, userEnvKey, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("error opening environment key: %w", err)
+	}
+	defer key.Close()
+
+	for name, value := range vars {
+		if value == "" {
+			if err := key.DeleteValue(name); err != nil && !errors.Is(err, registry.ErrNotExist) {
+				return fmt.Errorf("error deleting variable '%s': %w", name, err)
+			}
+			continue
+		}
+
+		// Values referencing other variables need to be stored as expandable
+		// strings. Existing expandable strings, such as the Path, keep their
+		// type.
+		_, valueType, err := key.GetStringValue(name)
+		if err != nil && !errors.Is(err, registry.ErrNotExist) && !errors.Is(err, registry.ErrUnexpectedType) {
+			return fmt.Errorf("error reading variable '%s': %w", name, err)
+		}
+		if valueType == registry.EXPAND_SZ || strings.Contains(value, "%") {
+			err = key.SetExpandStringValue(name, value)
+		} else {
+			err = key.SetStringValue(name, value)
+		}
+		if err != nil {
+			return fmt.Errorf("error setting variable '%s': %w", name, err)
+		}
+	}
+
+	broadcastEnvChange()
+	return nil
+}
+
+// broadcastEnvChange does the same as [Environment]::SetEnvironmentVariable,
+// notifying all top-level windows, such as the explorer, that the environment
+// has changed. Failure isn't critical, as the values have been persisted.
+func broadcastEnvChange() {
+	const (
+		hwndBroadcast    = 0xffff
+		wmSettingChange  = 0x001A
+		smtoAbortIfHung  = 0x0002
+		timeoutInMillies = 5000
+	)
+
+	environment, err := syscall.UTF16PtrFromString("Environment")
+	if err != nil {
+		return
+	}
+	var result uintptr
+	procSendMessageTimeout.Call(
+		hwndBroadcast,
+		wmSettingChange,
+		0,
+		uintptr(unsafe.Pointer(environment)),
+		smtoAbortIfHung,
+		timeoutInMillies,
+		uintptr(unsafe.Pointer(&result)),
+	)
+}
+
+// StartMenuDir returns the start menu directory of the current user.
+func StartMenuDir() (string, error) {
+	return sys.KnownFolderPath(sys.FOLDERID_StartMenu, 0)
+}
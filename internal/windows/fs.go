@@ -42,38 +42,36 @@ func ExtractDir(dirToExtract, destinationDir string) error {
 }
 
 // ForceRemoveAll will delete any file or folder recursively. This will not
-// delete the content of junctions.
+// delete the content of links, such as junctions and symlinks.
 func ForceRemoveAll(path string) error {
 	if err := os.Remove(path); err == nil || os.IsNotExist(err) {
 		return nil
 	}
 
-	if err := os.Chmod(path, 0o600); err != nil {
-		return fmt.Errorf("error marking path read-only")
-	}
-
-	info, err := os.Stat(path)
+	info, err := os.Lstat(path)
 	if err != nil {
 		return fmt.Errorf("error stating file for deletion: %w", err)
 	}
 
-	if !info.IsDir() {
-		// Try to delete again, now that it is marked read-only
+	// Links are removed without touching the target, so we mustn't change
+	// the permissions, as they'd apply to the target.
+	if isLinkInfo(info) {
 		return os.Remove(path)
 	}
 
-	// This also resolves junctions.
-	resolved, err := filepath.EvalSymlinks(path)
-	if err != nil {
-		return fmt.Errorf("error resolving links: %w", err)
+	// Read-only files can't be deleted on Windows. Dirs additionally need to
+	// stay listable and traversable on other platforms.
+	perm := info.Mode().Perm() | 0o600
+	if info.IsDir() {
+		perm |= 0o100
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		return fmt.Errorf("error making path writable: %w", err)
 	}
 
-	// Workaround for junctions, as they can be deleted directly. Can we use the
-	// stat call to identify the junction?
-	if resolved != path {
-		if err := os.Remove(path); err == nil || os.IsNotExist(err) {
-			return nil
-		}
+	if !info.IsDir() {
+		// Try to delete again, now that it is writable.
+		return os.Remove(path)
 	}
 
 	files, err := GetDirFilenames(path)
@@ -100,35 +98,17 @@ func GetDirFilenames(dir string) ([]string, error) {
 	return dirHandle.Readdirnames(-1)
 }
 
-// CreateJunction will create multiple junctions. Each pair reflects one
-// junction ([2]string{existing_dir, link_location}). A junction as a hardlink
-// to a directory. This means, that we hardlink to the drive-sections, which
-// results in deletions not affecting the actual data, as long as there are
-// still references.
-func CreateJunctions(junctions ...[2]string) error {
-	for _, junction := range junctions {
-		from, err := filepath.Abs(junction[0])
-		if err != nil {
-			return fmt.Errorf("error creating absolute path: %w", err)
-		}
-		junction[0] = from
-
-		to, err := filepath.Abs(junction[1])
-		if err != nil {
-			return fmt.Errorf("error creating absolute path: %w", err)
-		}
-		junction[1] = to
-	}
-
-	scriptLines := make([]string, 0, len(junctions))
-	for _, junction := range junctions {
-		// No need to re-create a junction
-		if _, err := os.Stat(junction[1]); err == nil {
-			continue
-		}
-
-		scriptLines = append(scriptLines, fmt.Sprintf(`mklink /J "%s" "%s"`, junction[1], junction[0]))
+// IsLink checks whether the given path is a symlink or junction, without
+// following it.
+func IsLink(path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
 	}
+	return isLinkInfo(info), nil
+}
 
-	return RunAndPipeInto("cmd", nil, scriptLines)
+func isLinkInfo(info fs.FileInfo) bool {
+	// Junctions aren't reported as symlinks, but as irregular files.
+	return !info.IsDir() && info.Mode()&(os.ModeSymlink|os.ModeIrregular) != 0
 }
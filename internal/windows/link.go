@@ -0,0 +1,38 @@
+package windows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SymlinkLinker links directories using symbolic links. On Windows, creating
+// symbolic links requires elevated privileges or developer mode, so this is
+// meant for other platforms.
+type SymlinkLinker struct{}
+
+// LinkDir creates a symbolic link at link, pointing to the target dir. If
+// something already exists at link, nothing happens.
+func (SymlinkLinker) LinkDir(target, link string) error {
+	target, link, err := absLinkPaths(target, link)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(link); err == nil {
+		return nil
+	}
+	return os.Symlink(target, link)
+}
+
+func absLinkPaths(target, link string) (string, string, error) {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating absolute path: %w", err)
+	}
+	link, err = filepath.Abs(link)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating absolute path: %w", err)
+	}
+	return target, link, nil
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package windows
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// JunctionLinker links directories using junctions. A junction is a hardlink
+// to a directory. This means, that we hardlink to the drive-sections, which
+// results in deletions not affecting the actual data, as long as there are
+// still references. Unlike symbolic links, junctions don't require elevated
+// privileges.
+type JunctionLinker struct{}
+
+// LinkDir creates a junction at link, pointing to the target dir. If
+// something already exists at link, nothing happens.
+func (JunctionLinker) LinkDir(target, link string) error {
+	target, link, err := absLinkPaths(target, link)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(link); err == nil {
+		return nil
+	}
+
+	// mklink is a builtin of cmd, so there's no executable to call directly.
+	output, err := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error creating junction: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DefaultLinker is the linker for the current platform.
+type DefaultLinker = JunctionLinker
@@ -2,6 +2,8 @@
 
 package windows
 
+import "errors"
+
 func Arch() string { return "amd64" }
 
 type Shortcut struct {
@@ -38,3 +40,27 @@ func ProcessList() ([]Process, error) {
 func ProcessKill(pid uint32) (bool, error) {
 	return true, nil
 }
+
+// UserEnv is empty and ignores all changes, as there is no persistent user
+// environment on other platforms.
+type UserEnv struct{}
+
+func (UserEnv) Values() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (UserEnv) Get(key string) (string, string, error) {
+	return "", "", nil
+}
+
+func (UserEnv) Set(vars map[string]string) error {
+	return nil
+}
+
+// StartMenuDir returns [errors.ErrUnsupported], as there is no start menu.
+func StartMenuDir() (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+// DefaultLinker is the linker for the current platform.
+type DefaultLinker = SymlinkLinker
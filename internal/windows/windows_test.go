@@ -2,6 +2,7 @@ package windows_test
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -9,29 +10,47 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestCreateJunctions(t *testing.T) {
+func TestDefaultLinker(t *testing.T) {
 	t.Parallel()
 
-	if !strings.EqualFold("TRUE", os.Getenv("DESTRUCTIVE_TESTS")) {
-		t.SkipNow()
-	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dir_a")
+	link := filepath.Join(dir, "dir_a_link")
+	require.NoError(t, os.MkdirAll(target, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "file"), []byte("content"), 0o600))
 
-	t.Cleanup(func() {
-		os.RemoveAll("./dir_a")
-		os.RemoveAll("./dir_b")
-		os.RemoveAll("./dir_a_junc")
-		os.RemoveAll("./dir_b_junc")
-	})
+	require.NoError(t, windows.DefaultLinker{}.LinkDir(target, link))
+	require.FileExists(t, filepath.Join(link, "file"))
+	isLink, err := windows.IsLink(link)
+	require.NoError(t, err)
+	require.True(t, isLink)
+
+	// Existing links are kept.
+	require.NoError(t, windows.DefaultLinker{}.LinkDir(t.TempDir(), link))
+	require.FileExists(t, filepath.Join(link, "file"))
+}
+
+func TestForceRemoveAll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	linkTarget := filepath.Join(dir, "target")
+	require.NoError(t, os.MkdirAll(linkTarget, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(linkTarget, "file"), []byte("content"), 0o600))
+
+	toRemove := filepath.Join(dir, "remove")
+	require.NoError(t, os.MkdirAll(filepath.Join(toRemove, "sub"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(toRemove, "sub", "readonly"), []byte("content"), 0o400))
+	require.NoError(t, windows.DefaultLinker{}.LinkDir(linkTarget, filepath.Join(toRemove, "link")))
+	require.NoError(t, os.Chmod(filepath.Join(toRemove, "sub"), 0o500))
 
-	require.NoError(t, os.MkdirAll("./dir_a", os.ModeDir))
-	require.NoError(t, os.MkdirAll("./dir_b", os.ModeDir))
+	require.NoError(t, windows.ForceRemoveAll(toRemove))
+	require.NoDirExists(t, toRemove)
+	// The content of links must not be deleted.
+	require.FileExists(t, filepath.Join(linkTarget, "file"))
 
-	require.NoError(t, windows.CreateJunctions(
-		[2]string{"./dir_a", "./dir_a_junc"},
-		[2]string{"./dir_b", "./dir_b_junc"},
-	))
-	require.FileExists(t, "./dir_a_junc")
-	require.FileExists(t, "./dir_b_junc")
+	// Missing paths aren't an error.
+	require.NoError(t, windows.ForceRemoveAll(toRemove))
 }
 
 func TestCreateShortcuts(t *testing.T) {
@@ -0,0 +1,273 @@
+package scoop
+
+import (
+	stdJson "encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// AuditRule identifies a risky pattern found in a manifest.
+type AuditRule string
+
+const (
+	// AuditRuleDownload matches scripts downloading files, which bypasses
+	// the hash verification of manifest URLs.
+	AuditRuleDownload AuditRule = "download"
+	// AuditRuleRegistry matches scripts writing to the registry.
+	AuditRuleRegistry AuditRule = "registry"
+	// AuditRuleStartProcess matches scripts starting other processes.
+	AuditRuleStartProcess AuditRule = "start-process"
+	// AuditRuleExecutionPolicy matches scripts changing the execution
+	// policy.
+	AuditRuleExecutionPolicy AuditRule = "execution-policy"
+	// AuditRuleHTTP matches unencrypted URLs in scripts and downloads.
+	AuditRuleHTTP AuditRule = "http"
+	// AuditRuleMissingHash matches downloads without a hash.
+	AuditRuleMissingHash AuditRule = "missing-hash"
+	// AuditRulePSModule matches apps providing a PowerShell module, which is
+	// loadable in every PowerShell session.
+	AuditRulePSModule AuditRule = "psmodule"
+)
+
+// AuditRules contains all rules in the order they are checked.
+var AuditRules = []AuditRule{
+	AuditRuleDownload,
+	AuditRuleRegistry,
+	AuditRuleStartProcess,
+	AuditRuleExecutionPolicy,
+	AuditRuleHTTP,
+	AuditRuleMissingHash,
+	AuditRulePSModule,
+}
+
+// scriptPatterns are checked against each line of the scripts. PowerShell
+// is case insensitive, so are the patterns.
+var scriptPatterns = map[AuditRule]*regexp.Regexp{
+	AuditRuleDownload:        regexp.MustCompile(`(?i)\b(Invoke-WebRequest|iwr|Invoke-RestMethod|irm|Start-BitsTransfer|curl|wget)\b|Net\.WebClient|\.Download(File|String|Data)`),
+	AuditRuleRegistry:        regexp.MustCompile(`(?i)\b(HKLM|HKCU|HKCR|HKU|HKCC):|Registry::|\bHKEY_|\b(New|Set|Remove)-ItemProperty\b|\breg(\.exe)?\s+(add|delete|import|copy|restore|load)\b`),
+	AuditRuleStartProcess:    regexp.MustCompile(`(?i)\b(Start-Process|saps)\b|Diagnostics\.Process\]::Start`),
+	AuditRuleExecutionPolicy: regexp.MustCompile(`(?i)\bSet-ExecutionPolicy\b|-ExecutionPolicy\b`),
+	AuditRuleHTTP:            regexp.MustCompile(`(?i)\bhttp://`),
+}
+
+// AuditFinding is a risky pattern found in a manifest.
+type AuditFinding struct {
+	Rule AuditRule `json:"rule"`
+	// Field is the manifest field the pattern was found in, such as
+	// `pre_install` or `url`.
+	Field string `json:"field"`
+	// Value is the offending script line or field value.
+	Value string `json:"value"`
+}
+
+// AuditScript is a script with all manifest variables substituted.
+// Environment variables are kept as they are.
+type AuditScript struct {
+	Hook  ScriptHook `json:"hook"`
+	Lines []string   `json:"lines"`
+}
+
+// AuditReport lists everything an installation runs apart from the actual
+// installation files, allowing a user to review a manifest before installing
+// it.
+type AuditReport struct {
+	App          string          `json:"app"`
+	Version      string          `json:"version"`
+	Architecture ArchitectureKey `json:"architecture"`
+	Scripts      []AuditScript   `json:"scripts"`
+	PSModule     string          `json:"psmodule,omitempty"`
+	Findings     []AuditFinding  `json:"findings"`
+}
+
+// Audit reviews the scripts and downloads of the app for the given
+// architecture. The details of the app need to be loaded. Manifest variables
+// in the scripts are substituted with the values they'd have on installation.
+// Environment variables aren't, as the report mustn't leak the environment of
+// the auditing process.
+func (scoop *Scoop) Audit(app *App, arch ArchitectureKey) *AuditReport {
+	resolvedApp := app.ForArch(arch)
+	appDir := filepath.Join(scoop.AppDir(), app.Name)
+	versionDir := filepath.Join(appDir, app.Version)
+	installVariables := scoop.manifestVariables(app, arch, versionDir, versionDir)
+	linkVariables := scoop.manifestVariables(app, arch, filepath.Join(appDir, "current"), versionDir)
+
+	report := &AuditReport{
+		App:          app.Name,
+		Version:      app.Version,
+		Architecture: arch,
+		PSModule:     app.PSModule,
+		Scripts:      []AuditScript{},
+		Findings:     []AuditFinding{},
+	}
+
+	addScript := func(hook ScriptHook, lines []string, variables manifestVariables) {
+		if len(lines) == 0 {
+			return
+		}
+
+		script := AuditScript{Hook: hook, Lines: make([]string, len(lines))}
+		for index, line := range lines {
+			script.Lines[index] = variables.substituteManifest(line)
+		}
+		report.Scripts = append(report.Scripts, script)
+	}
+	addScript(ScriptHookPreInstall, resolvedApp.PreInstall, installVariables)
+	if installer := resolvedApp.Installer; installer != nil {
+		addScript(ScriptHookInstaller, installer.Script, installVariables.with("fname", installer.File))
+	}
+	addScript(ScriptHookPostInstall, resolvedApp.PostInstall, linkVariables)
+	addScript(ScriptHookPreUninstall, resolvedApp.PreUninstall, installVariables)
+	if uninstaller := resolvedApp.Uninstaller; uninstaller != nil {
+		addScript(ScriptHookUninstaller, uninstaller.Script, installVariables.with("fname", uninstaller.File))
+	}
+	addScript(ScriptHookPostUninstall, resolvedApp.PostUninstall, installVariables)
+
+	for _, script := range report.Scripts {
+		for _, line := range script.Lines {
+			for _, rule := range AuditRules {
+				if pattern := scriptPatterns[rule]; pattern != nil && pattern.MatchString(line) {
+					report.Findings = append(report.Findings, AuditFinding{
+						Rule:  rule,
+						Field: string(script.Hook),
+						Value: strings.TrimSpace(line),
+					})
+				}
+			}
+		}
+	}
+
+	for _, downloadable := range resolvedApp.Downloadables {
+		if scriptPatterns[AuditRuleHTTP].MatchString(downloadable.URL) {
+			report.Findings = append(report.Findings, AuditFinding{
+				Rule:  AuditRuleHTTP,
+				Field: DetailFieldUrl,
+				Value: downloadable.URL,
+			})
+		}
+		if downloadable.Hash == "" {
+			report.Findings = append(report.Findings, AuditFinding{
+				Rule:  AuditRuleMissingHash,
+				Field: DetailFieldHash,
+				Value: downloadable.URL,
+			})
+		}
+	}
+
+	if app.PSModule != "" {
+		report.Findings = append(report.Findings, AuditFinding{
+			Rule:  AuditRulePSModule,
+			Field: DetailFieldPSModule,
+			Value: app.PSModule,
+		})
+	}
+
+	return report
+}
+
+// AuditAvailableApp audits the manifest that installing the given app would
+// use. Just like for installation, a specific version can be requested, such
+// as `app@1.0.0`.
+func (scoop *Scoop) AuditAvailableApp(appName string, arch ArchitectureKey) (*AuditReport, error) {
+	app, err := scoop.FindAvailableApp(appName)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, ErrAppNotFound
+	}
+
+	_, _, version := ParseAppIdentifier(appName)
+	if version == "" {
+		if err := app.LoadDetails(DetailFieldsAll...); err != nil {
+			return nil, fmt.Errorf("error loading manifest: %w", err)
+		}
+		return scoop.Audit(app, arch), nil
+	}
+
+	manifestFile, err := app.ManifestForVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("error finding app in version: %w", err)
+	}
+	if manifestFile == nil {
+		return nil, ErrAppNotAvailableInVersion
+	}
+	if closer, ok := manifestFile.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	app = &App{
+		Name:   app.Name,
+		Bucket: app.Bucket,
+	}
+	if err := app.loadDetailFromManifestWithIter(manifestIter(), manifestFile, DetailFieldsAll...); err != nil {
+		return nil, fmt.Errorf("error loading manifest: %w", err)
+	}
+	return scoop.Audit(app, arch), nil
+}
+
+// AuditPolicy decides which findings prevent an installation.
+type AuditPolicy struct {
+	// Block contains all rules that prevent installation if matched.
+	Block []AuditRule `json:"block"`
+}
+
+// AuditPolicyPath is the default location of the audit policy.
+func (scoop *Scoop) AuditPolicyPath() string {
+	return filepath.Join(scoop.SpoonDir(), "audit_policy.json")
+}
+
+// LoadAuditPolicy reads the policy at the given path. If the file doesn't
+// exist, nil is returned without an error.
+func LoadAuditPolicy(path string) (*AuditPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading audit policy: %w", err)
+	}
+
+	var policy AuditPolicy
+	if err := stdJson.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("error parsing audit policy: %w", err)
+	}
+	for _, rule := range policy.Block {
+		if !slices.Contains(AuditRules, rule) {
+			return nil, fmt.Errorf("unknown audit rule '%s' in policy", rule)
+		}
+	}
+	return &policy, nil
+}
+
+// Blocked returns all findings that violate the policy.
+func (policy *AuditPolicy) Blocked(report *AuditReport) []AuditFinding {
+	var blocked []AuditFinding
+	for _, finding := range report.Findings {
+		if slices.Contains(policy.Block, finding.Rule) {
+			blocked = append(blocked, finding)
+		}
+	}
+	return blocked
+}
+
+// AuditBlockedError is returned when installing an app that violates the
+// [Scoop.AuditPolicy].
+type AuditBlockedError struct {
+	App      string
+	Findings []AuditFinding
+}
+
+func (err *AuditBlockedError) Error() string {
+	rules := make([]string, 0, len(err.Findings))
+	for _, finding := range err.Findings {
+		if !slices.Contains(rules, string(finding.Rule)) {
+			rules = append(rules, string(finding.Rule))
+		}
+	}
+	return fmt.Sprintf("installation of '%s' blocked by audit policy: %s", err.App, strings.Join(rules, ", "))
+}
@@ -0,0 +1,183 @@
+package scoop_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/scooptest"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Audit(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	app := &scoop.App{
+		Name:    "app",
+		Version: "1.0.0",
+		Downloadables: []scoop.Downloadable{
+			{URL: "https://example.com/app.zip", Hash: "abc"},
+			{URL: "http://example.com/plugin.zip"},
+		},
+		PreInstall: []string{
+			"Copy-Item \"$dir\\default.cfg\" \"$persist_dir\"",
+			"Invoke-WebRequest https://example.com/extra.zip -OutFile \"$dir\\extra.zip\"",
+		},
+		Installer: &scoop.Installer{
+			Script: []string{"Start-Process \"$dir\\$fname\" -Wait"},
+			File:   "setup.exe",
+		},
+		PostInstall: []string{
+			"Set-ItemProperty -Path 'HKCU:\\Software\\App' -Name 'Home' -Value $dir",
+			"Set-ExecutionPolicy RemoteSigned -Scope CurrentUser",
+		},
+		Uninstaller: &scoop.Uninstaller{
+			Script: []string{
+				"reg delete HKCU\\Software\\App /f",
+				"Remove-Item \"$env:PATH\\$app\"",
+			},
+		},
+		PSModule: "AppModule",
+	}
+
+	report := customScoop.Audit(app, scoop.ArchitectureKey64Bit)
+
+	versionDir := filepath.Join(customScoop.AppDir(), "app", "1.0.0")
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	persistDir := customScoop.AppPersistDir("app")
+	require.Equal(t, []scoop.AuditScript{
+		{Hook: scoop.ScriptHookPreInstall, Lines: []string{
+			"Copy-Item \"" + versionDir + "\\default.cfg\" \"" + persistDir + "\"",
+			"Invoke-WebRequest https://example.com/extra.zip -OutFile \"" + versionDir + "\\extra.zip\"",
+		}},
+		{Hook: scoop.ScriptHookInstaller, Lines: []string{
+			"Start-Process \"" + versionDir + "\\setup.exe\" -Wait",
+		}},
+		{Hook: scoop.ScriptHookPostInstall, Lines: []string{
+			"Set-ItemProperty -Path 'HKCU:\\Software\\App' -Name 'Home' -Value " + currentDir,
+			"Set-ExecutionPolicy RemoteSigned -Scope CurrentUser",
+		}},
+		{Hook: scoop.ScriptHookUninstaller, Lines: []string{
+			"reg delete HKCU\\Software\\App /f",
+			// The environment of the auditing process isn't leaked.
+			"Remove-Item \"$env:PATH\\app\"",
+		}},
+	}, report.Scripts)
+
+	rules := make(map[scoop.AuditRule][]string)
+	for _, finding := range report.Findings {
+		rules[finding.Rule] = append(rules[finding.Rule], finding.Field)
+	}
+	require.Equal(t, map[scoop.AuditRule][]string{
+		scoop.AuditRuleDownload:        {"pre_install"},
+		scoop.AuditRuleStartProcess:    {"installer.script"},
+		scoop.AuditRuleRegistry:        {"post_install", "uninstaller.script"},
+		scoop.AuditRuleExecutionPolicy: {"post_install"},
+		scoop.AuditRuleHTTP:            {"url"},
+		scoop.AuditRuleMissingHash:     {"hash"},
+		scoop.AuditRulePSModule:        {"psmodule"},
+	}, rules)
+
+	t.Run("clean", func(t *testing.T) {
+		t.Parallel()
+
+		report := customScoop.Audit(&scoop.App{
+			Name:          "clean",
+			Version:       "1.0.0",
+			Downloadables: []scoop.Downloadable{{URL: "https://example.com/app.zip", Hash: "abc"}},
+			PostInstall:   []string{"Write-Host 'Installed to $dir'", "# Not a download: irmgard"},
+		}, scoop.ArchitectureKey64Bit)
+		require.Empty(t, report.Findings)
+	})
+}
+
+func Test_AuditAvailableApp_ArchitectureScripts(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	scooptest.WriteManifest(t, customScoop, "app", `{
+    "version": "1.0.0",
+    "architecture": {
+        "64bit": {
+            "url": "https://example.com/app-x64.zip",
+            "hash": "abc",
+            "installer": {"script": "Start-Process \"$dir\\setup.exe\" -Wait"},
+            "uninstaller": {"script": "reg delete HKCU\\Software\\App /f"}
+        },
+        "32bit": {
+            "url": "https://example.com/app-x86.zip",
+            "hash": "def"
+        }
+    }
+}`)
+
+	report, err := customScoop.AuditAvailableApp("app", scoop.ArchitectureKey64Bit)
+	require.NoError(t, err)
+	versionDir := filepath.Join(customScoop.AppDir(), "app", "1.0.0")
+	require.Equal(t, []scoop.AuditScript{
+		{Hook: scoop.ScriptHookInstaller, Lines: []string{"Start-Process \"" + versionDir + "\\setup.exe\" -Wait"}},
+		{Hook: scoop.ScriptHookUninstaller, Lines: []string{"reg delete HKCU\\Software\\App /f"}},
+	}, report.Scripts)
+	require.Equal(t, []scoop.AuditFinding{
+		{Rule: scoop.AuditRuleStartProcess, Field: "installer.script", Value: "Start-Process \"" + versionDir + "\\setup.exe\" -Wait"},
+		{Rule: scoop.AuditRuleRegistry, Field: "uninstaller.script", Value: "reg delete HKCU\\Software\\App /f"},
+	}, report.Findings)
+
+	report, err = customScoop.AuditAvailableApp("app", scoop.ArchitectureKey32Bit)
+	require.NoError(t, err)
+	require.Empty(t, report.Scripts)
+	require.Empty(t, report.Findings)
+}
+
+func Test_AuditPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := scoop.LoadAuditPolicy(filepath.Join(t.TempDir(), "audit_policy.json"))
+		require.NoError(t, err)
+		require.Nil(t, policy)
+	})
+	t.Run("unknown rule", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit_policy.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"block": ["downloads"]}`), 0o600))
+		_, err := scoop.LoadAuditPolicy(path)
+		require.Error(t, err)
+	})
+	t.Run("install blocked", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop := scoop.NewCustomScoop(t.TempDir())
+		scooptest.WriteManifest(t, customScoop, "app", `{
+    "version": "1.0.0",
+    "url": "https://example.com/app.zip",
+    "pre_install": "Invoke-WebRequest http://example.com/a.zip"
+}`)
+
+		path := customScoop.AuditPolicyPath()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(`{"block": ["missing-hash", "download"]}`), 0o600))
+		policy, err := scoop.LoadAuditPolicy(path)
+		require.NoError(t, err)
+		customScoop.AuditPolicy = policy
+		scripts := &scoop.RecordingScriptRunner{}
+		customScoop.ScriptRunner = scripts
+
+		err = customScoop.Install("app", scoop.ArchitectureKey64Bit)
+		var blockedErr *scoop.AuditBlockedError
+		require.ErrorAs(t, err, &blockedErr)
+		require.Equal(t, "app", blockedErr.App)
+		require.Equal(t, []scoop.AuditFinding{
+			{Rule: scoop.AuditRuleDownload, Field: "pre_install", Value: "Invoke-WebRequest http://example.com/a.zip"},
+			{Rule: scoop.AuditRuleMissingHash, Field: "hash", Value: "https://example.com/app.zip"},
+		}, blockedErr.Findings)
+
+		require.Empty(t, scripts.Scripts())
+		require.NoDirExists(t, filepath.Join(customScoop.AppDir(), "app"))
+	})
+}
@@ -0,0 +1,118 @@
+package scoop
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EnvStore provides access to the persistent environment variables of the
+// user. Apps set variables and add entries to the path on installation and
+// remove them again on uninstallation.
+type EnvStore interface {
+	// Values returns all variables.
+	Values() (map[string]string, error)
+	// Get retrieves a variable case insensitively. The first returned value
+	// is the key in its stored casing and the second the value. If the
+	// variable doesn't exist, empty strings are returned without an error.
+	Get(key string) (string, string, error)
+	// Set sets all given variables. An empty value removes the variable.
+	Set(vars map[string]string) error
+}
+
+// AppEnvVars returns the variables the installed app sets, with all manifest
+// variables substituted, just like on installation. The details of the app
+// need to contain the version and env_set.
+func (scoop *Scoop) AppEnvVars(app *InstalledApp) []EnvVar {
+	appDir := filepath.Join(scoop.AppDir(), app.Name)
+	variables := scoop.manifestVariables(app.App, app.Architecture,
+		filepath.Join(appDir, "current"), filepath.Join(appDir, app.Version))
+
+	envVars := make([]EnvVar, 0, len(app.EnvSet))
+	for _, envVar := range app.EnvSet {
+		envVar.Value = variables.substitute(envVar.Value)
+		envVars = append(envVars, envVar)
+	}
+	return envVars
+}
+
+// AppPathEntries returns the env_add_path entries of the app. Just like in
+// scoop, relative entries are relative to the `current` dir of the app, while
+// absolute entries are kept as they are.
+func (scoop *Scoop) AppPathEntries(app *App) []string {
+	currentDir := filepath.Join(scoop.AppDir(), app.Name, "current")
+	entries := make([]string, 0, len(app.EnvAddPath))
+	for _, entry := range app.EnvAddPath {
+		entry = manifestPath(entry)
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(currentDir, entry)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// MemoryEnvStore is an [EnvStore] that only keeps the variables in memory.
+// This is useful for tests or if the actual user environment mustn't be
+// touched.
+type MemoryEnvStore struct {
+	mutex sync.Mutex
+	vars  map[string]string
+}
+
+// NewMemoryEnvStore creates a store containing a copy of the given variables.
+func NewMemoryEnvStore(vars map[string]string) *MemoryEnvStore {
+	store := &MemoryEnvStore{vars: make(map[string]string, len(vars))}
+	maps.Copy(store.vars, vars)
+	return store
+}
+
+func (store *MemoryEnvStore) Values() (map[string]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return maps.Clone(store.vars), nil
+}
+
+func (store *MemoryEnvStore) Get(key string) (string, string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	storedKey, value, _ := lookupEnv(store.vars, key)
+	return storedKey, value, nil
+}
+
+func (store *MemoryEnvStore) Set(vars map[string]string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for key, value := range vars {
+		// Keys are case insensitive, so we overwrite existing keys, keeping
+		// their casing.
+		if storedKey, _, ok := lookupEnv(store.vars, key); ok {
+			key = storedKey
+		}
+
+		if value == "" {
+			delete(store.vars, key)
+		} else {
+			store.vars[key] = value
+		}
+	}
+	return nil
+}
+
+// lookupEnv looks up the key case insensitively, as Windows does. The key is
+// returned in its stored casing.
+func lookupEnv(vars map[string]string, key string) (string, string, bool) {
+	if value, ok := vars[key]; ok {
+		return key, value, true
+	}
+	for storedKey, value := range vars {
+		if strings.EqualFold(storedKey, key) {
+			return storedKey, value, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,284 @@
+package scoop
+
+import (
+	stdJson "encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+)
+
+// EnvVarRecord is a persistent environment variable set by an app.
+type EnvVarRecord struct {
+	Key string `json:"key"`
+	// Value is the value set by the app.
+	Value string `json:"value"`
+	// Existed indicates whether the variable existed before the app was
+	// installed. Only then Previous is valid.
+	Existed bool `json:"existed"`
+	// Previous is the value before the app has been installed.
+	Previous string `json:"previous,omitempty"`
+}
+
+// AppEnvRecord contains all changes an app made to the persistent
+// environment.
+type AppEnvRecord struct {
+	Vars []*EnvVarRecord `json:"vars,omitempty"`
+	// Path contains all entries that the app added to the path. Entries that
+	// were already present before the installation aren't included.
+	Path []string `json:"path,omitempty"`
+}
+
+// Var returns the record of the given variable or nil.
+func (record *AppEnvRecord) Var(key string) *EnvVarRecord {
+	for _, varRecord := range record.Vars {
+		if strings.EqualFold(varRecord.Key, key) {
+			return varRecord
+		}
+	}
+	return nil
+}
+
+// EnvLedger records the changes apps made to the persistent environment.
+// Apps installed before spoon started recording changes are missing.
+type EnvLedger struct {
+	// Apps maps lowercased app names to their changes.
+	Apps map[string]*AppEnvRecord `json:"apps"`
+	// Stacks maps lowercased variable keys to the lowercased names of the
+	// apps that set them, in the order of installation. The value of the
+	// last app is the active one.
+	Stacks map[string][]string `json:"stacks,omitempty"`
+}
+
+// Get returns the record for the given app or nil.
+func (ledger *EnvLedger) Get(appName string) *AppEnvRecord {
+	return ledger.Apps[strings.ToLower(appName)]
+}
+
+// Set records the changes of the app. Passing nil removes the record.
+func (ledger *EnvLedger) Set(appName string, record *AppEnvRecord) {
+	if record == nil {
+		delete(ledger.Apps, strings.ToLower(appName))
+		return
+	}
+	ledger.Apps[strings.ToLower(appName)] = record
+}
+
+// push puts the app on top of the stack of the variable.
+func (ledger *EnvLedger) push(appName, key string) {
+	key = strings.ToLower(key)
+	ledger.Stacks[key] = append(ledger.Stacks[key], strings.ToLower(appName))
+}
+
+// pop removes the app from the stack of the variable and returns whether its
+// value was the active one. The app installed right after it recorded its
+// value as the previous one, so it inherits the previous state of the app.
+// Apps missing in the stack are considered active.
+func (ledger *EnvLedger) pop(appName string, varRecord *EnvVarRecord) bool {
+	key := strings.ToLower(varRecord.Key)
+	stack := ledger.Stacks[key]
+	index := slices.Index(stack, strings.ToLower(appName))
+	if index == -1 {
+		return true
+	}
+
+	active := index == len(stack)-1
+	if !active {
+		if next := ledger.Get(stack[index+1]); next != nil {
+			if nextVar := next.Var(key); nextVar != nil {
+				nextVar.Existed = varRecord.Existed
+				nextVar.Previous = varRecord.Previous
+			}
+		}
+	}
+
+	stack = slices.Delete(stack, index, index+1)
+	if len(stack) == 0 {
+		delete(ledger.Stacks, key)
+	} else {
+		ledger.Stacks[key] = stack
+	}
+	return active
+}
+
+func (scoop *Scoop) envLedgerPath() string {
+	return filepath.Join(scoop.SpoonDir(), "env.json")
+}
+
+// LoadEnvLedger reads the env ledger. If no ledger has been written yet, an
+// empty ledger is returned.
+func (scoop *Scoop) LoadEnvLedger() (*EnvLedger, error) {
+	ledger := &EnvLedger{
+		Apps:   make(map[string]*AppEnvRecord),
+		Stacks: make(map[string][]string),
+	}
+	data, err := os.ReadFile(scoop.envLedgerPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ledger, nil
+		}
+		return nil, fmt.Errorf("error reading env ledger: %w", err)
+	}
+
+	if err := stdJson.Unmarshal(data, ledger); err != nil {
+		return nil, fmt.Errorf("error parsing env ledger: %w", err)
+	}
+	// Unmarshalling sets empty maps to nil.
+	if ledger.Apps == nil {
+		ledger.Apps = make(map[string]*AppEnvRecord)
+	}
+	if ledger.Stacks == nil {
+		ledger.Stacks = make(map[string][]string)
+	}
+	return ledger, nil
+}
+
+// SaveEnvLedger persists the given ledger, replacing the existing one.
+func (scoop *Scoop) SaveEnvLedger(ledger *EnvLedger) error {
+	if err := os.MkdirAll(scoop.SpoonDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating spoon dir: %w", err)
+	}
+
+	data, err := stdJson.MarshalIndent(ledger, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error encoding env ledger: %w", err)
+	}
+	if err := os.WriteFile(scoop.envLedgerPath(), data, 0o600); err != nil {
+		return fmt.Errorf("error writing env ledger: %w", err)
+	}
+	return nil
+}
+
+// setAppEnv sets the variables and prepends the path entries in the
+// persistent environment, recording the previous state in the ledger. When
+// resetting, the originally recorded state is kept, unless another app set
+// the variable since. In that case, the app becomes the active one again.
+func (scoop *Scoop) setAppEnv(appName string, vars []EnvVar, pathEntries []string) error {
+	if len(vars) == 0 && len(pathEntries) == 0 {
+		return nil
+	}
+
+	ledger, err := scoop.LoadEnvLedger()
+	if err != nil {
+		return err
+	}
+	current, err := scoop.EnvStore.Values()
+	if err != nil {
+		return fmt.Errorf("error retrieving environment variables: %w", err)
+	}
+
+	record := ledger.Get(appName)
+	if record == nil {
+		record = &AppEnvRecord{}
+	}
+
+	changes := make(map[string]string)
+	for _, envVar := range vars {
+		key := envVar.Key
+		storedKey, value, exists := lookupEnv(current, key)
+		if exists {
+			key = storedKey
+		}
+
+		varRecord := record.Var(key)
+		if varRecord == nil {
+			varRecord = &EnvVarRecord{Key: key, Existed: exists, Previous: value}
+			record.Vars = append(record.Vars, varRecord)
+		} else if !ledger.pop(appName, varRecord) {
+			// Another app set the variable since, so its value is the one
+			// to restore now.
+			varRecord.Existed = exists
+			varRecord.Previous = value
+		}
+		ledger.push(appName, key)
+		varRecord.Value = envVar.Value
+		changes[key] = envVar.Value
+	}
+
+	if len(pathEntries) > 0 {
+		pathKey, pathValue, exists := lookupEnv(current, "Path")
+		if !exists {
+			pathKey = "Path"
+		}
+
+		path := windows.ParsePath(pathValue)
+		for _, entry := range pathEntries {
+			if !path.Contains(entry) && !windows.Paths(record.Path).Contains(entry) {
+				record.Path = append(record.Path, entry)
+			}
+		}
+
+		// Existing entries are moved, so we don't add duplicates when
+		// resetting.
+		changes[pathKey] = path.Prepend(pathEntries...).String()
+	}
+
+	if err := scoop.EnvStore.Set(changes); err != nil {
+		return fmt.Errorf("error setting env values: %w", err)
+	}
+	ledger.Set(appName, record)
+	return scoop.SaveEnvLedger(ledger)
+}
+
+// unsetAppEnv reverts the changes the app made to the persistent environment.
+// Variables are restored to their previous value or removed, if they didn't
+// exist before. Variables changed by someone else since aren't touched, just
+// like variables set by apps installed afterwards. Only path entries added by
+// the app are removed.
+//
+// If the app isn't in the ledger, the previous state is unknown, so all
+// variables and path entries are removed.
+func (scoop *Scoop) unsetAppEnv(appName string, vars []EnvVar, pathEntries []string) error {
+	ledger, err := scoop.LoadEnvLedger()
+	if err != nil {
+		return err
+	}
+	record := ledger.Get(appName)
+	if record == nil && len(vars) == 0 && len(pathEntries) == 0 {
+		return nil
+	}
+
+	current, err := scoop.EnvStore.Values()
+	if err != nil {
+		return fmt.Errorf("error retrieving environment variables: %w", err)
+	}
+
+	changes := make(map[string]string)
+	if record == nil {
+		for _, envVar := range vars {
+			changes[envVar.Key] = ""
+		}
+	} else {
+		for _, varRecord := range record.Vars {
+			if !ledger.pop(appName, varRecord) {
+				continue
+			}
+
+			storedKey, value, exists := lookupEnv(current, varRecord.Key)
+			if !exists || value != varRecord.Value {
+				continue
+			}
+			if varRecord.Existed {
+				changes[storedKey] = varRecord.Previous
+			} else {
+				changes[storedKey] = ""
+			}
+		}
+		pathEntries = record.Path
+	}
+
+	if len(pathEntries) > 0 {
+		if pathKey, pathValue, exists := lookupEnv(current, "Path"); exists {
+			changes[pathKey] = windows.ParsePath(pathValue).Remove(pathEntries...).String()
+		}
+	}
+
+	if err := scoop.EnvStore.Set(changes); err != nil {
+		return fmt.Errorf("error restoring environment variables: %w", err)
+	}
+	ledger.Set(appName, nil)
+	return scoop.SaveEnvLedger(ledger)
+}
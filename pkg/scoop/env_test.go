@@ -0,0 +1,287 @@
+package scoop_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MemoryEnvStore(t *testing.T) {
+	t.Parallel()
+
+	store := scoop.NewMemoryEnvStore(map[string]string{"Path": `C:\bin`})
+
+	key, value, err := store.Get("PATH")
+	require.NoError(t, err)
+	require.Equal(t, "Path", key)
+	require.Equal(t, `C:\bin`, value)
+
+	key, value, err = store.Get("MISSING")
+	require.NoError(t, err)
+	require.Empty(t, key)
+	require.Empty(t, value)
+
+	require.NoError(t, store.Set(map[string]string{
+		"PATH":    `C:\other;C:\bin`,
+		"NEW":     "it's a value",
+		"MISSING": "",
+	}))
+	values, err := store.Values()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"Path": `C:\other;C:\bin`,
+		"NEW":  "it's a value",
+	}, values)
+
+	require.NoError(t, store.Set(map[string]string{"new": ""}))
+	values, err = store.Values()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Path": `C:\other;C:\bin`}, values)
+}
+
+func Test_UninstallEnv(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *scoop.InstalledApp {
+		return &scoop.InstalledApp{
+			App: &scoop.App{
+				Name:       "app",
+				Version:    "1.0.0",
+				EnvSet:     []scoop.EnvVar{{Key: "APP_HOME", Value: "$dir"}},
+				EnvAddPath: []string{`C:\scoop\apps\app\current\bin`},
+			},
+			Architecture: scoop.ArchitectureKey64Bit,
+		}
+	}
+	env := map[string]string{
+		"Path":      `"C:\other";"C:\scoop\apps\app\current\bin"`,
+		"APP_HOME":  `C:\scoop\apps\app\current`,
+		"UNRELATED": "value",
+	}
+
+	t.Run("persistent", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop := scoop.NewCustomScoop(t.TempDir())
+		store := scoop.NewMemoryEnvStore(env)
+		customScoop.EnvStore = store
+
+		app := newApp()
+		require.NoError(t, customScoop.Uninstall(app, app.Architecture))
+
+		values, err := store.Values()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"Path":      `"C:\other"`,
+			"UNRELATED": "value",
+		}, values)
+	})
+	t.Run("isolated", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop := scoop.NewCustomScoop(t.TempDir())
+		customScoop.Isolated = true
+		store := scoop.NewMemoryEnvStore(env)
+		customScoop.EnvStore = store
+
+		app := newApp()
+		require.NoError(t, customScoop.Uninstall(app, app.Architecture))
+
+		values, err := store.Values()
+		require.NoError(t, err)
+		require.Equal(t, env, values)
+	})
+}
+
+func Test_EnvLedger(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, env map[string]string) (*scoop.Scoop, *scoop.MemoryEnvStore) {
+		t.Helper()
+
+		customScoop := scoop.NewCustomScoop(t.TempDir())
+		store := scoop.NewMemoryEnvStore(env)
+		customScoop.EnvStore = store
+		return customScoop, store
+	}
+	requireEnv := func(t *testing.T, store *scoop.MemoryEnvStore, expected map[string]string) {
+		t.Helper()
+
+		values, err := store.Values()
+		require.NoError(t, err)
+		require.Equal(t, expected, values)
+	}
+	jdkVars := func(value string) []scoop.EnvVar {
+		return []scoop.EnvVar{{Key: "JAVA_HOME", Value: value}}
+	}
+
+	t.Run("restore previous value", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"java_home": `C:\jdk`})
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk", jdkVars(`C:\scoop\jdk`), nil))
+		requireEnv(t, store, map[string]string{"java_home": `C:\scoop\jdk`})
+
+		ledger, err := customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Equal(t, &scoop.AppEnvRecord{Vars: []*scoop.EnvVarRecord{{
+			Key:      "java_home",
+			Value:    `C:\scoop\jdk`,
+			Existed:  true,
+			Previous: `C:\jdk`,
+		}}}, ledger.Get("JDK"))
+
+		// Resetting keeps the originally recorded value.
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk", jdkVars(`C:\scoop\jdk`), nil))
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"java_home": `C:\jdk`})
+
+		ledger, err = customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Nil(t, ledger.Get("jdk"))
+	})
+	t.Run("remove created variable", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, nil)
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk", jdkVars(`C:\scoop\jdk`), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\scoop\jdk`})
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{})
+	})
+	t.Run("keep value changed by user", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, nil)
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk", jdkVars(`C:\scoop\jdk`), nil))
+		require.NoError(t, store.Set(map[string]string{"JAVA_HOME": `C:\custom`}))
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\custom`})
+	})
+	t.Run("chained apps", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"JAVA_HOME": `C:\jdk`})
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk8", jdkVars(`C:\scoop\jdk8`), nil))
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk17", jdkVars(`C:\scoop\jdk17`), nil))
+
+		// The newer app stays active, but now restores the user value.
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk8", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\scoop\jdk17`})
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk17", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\jdk`})
+	})
+	t.Run("chained apps with the same value", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"JAVA_HOME": `C:\jdk`})
+		require.NoError(t, scoop.SetAppEnv(customScoop, "a", jdkVars(`C:\same`), nil))
+		require.NoError(t, scoop.SetAppEnv(customScoop, "b", jdkVars(`C:\same`), nil))
+
+		// Only the app installed last restores the previous value.
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "a", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\same`})
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "b", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\jdk`})
+
+		ledger, err := customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Empty(t, ledger.Stacks)
+	})
+	t.Run("reset older app", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"JAVA_HOME": `C:\jdk`})
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk8", jdkVars(`C:\scoop\jdk8`), nil))
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk17", jdkVars(`C:\scoop\jdk17`), nil))
+
+		// Resetting makes the app the active one again.
+		require.NoError(t, scoop.SetAppEnv(customScoop, "jdk8", jdkVars(`C:\scoop\jdk8`), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\scoop\jdk8`})
+
+		ledger, err := customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Equal(t, []string{"jdk17", "jdk8"}, ledger.Stacks["java_home"])
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk8", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\scoop\jdk17`})
+
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "jdk17", jdkVars("$dir"), nil))
+		requireEnv(t, store, map[string]string{"JAVA_HOME": `C:\jdk`})
+	})
+	t.Run("path entries", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"PATH": `"C:\bin";"C:\shared"`})
+		pathEntries := []string{`C:\app`, `C:\shared`}
+		require.NoError(t, scoop.SetAppEnv(customScoop, "app", nil, pathEntries))
+		requireEnv(t, store, map[string]string{"PATH": `"C:\app";"C:\shared";"C:\bin"`})
+
+		ledger, err := customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Equal(t, []string{`C:\app`}, ledger.Get("app").Path)
+
+		// The entry existed before, so the user still needs it.
+		require.NoError(t, scoop.UnsetAppEnv(customScoop, "app", nil, pathEntries))
+		requireEnv(t, store, map[string]string{"PATH": `"C:\shared";"C:\bin"`})
+	})
+	t.Run("differently written path entries", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, store := setup(t, map[string]string{"PATH": `"C:\bin";"c:\App\"`})
+		require.NoError(t, scoop.SetAppEnv(customScoop, "app", nil, []string{`C:/app`}))
+		requireEnv(t, store, map[string]string{"PATH": `"C:/app";"C:\bin"`})
+
+		ledger, err := customScoop.LoadEnvLedger()
+		require.NoError(t, err)
+		require.Empty(t, ledger.Get("app").Path)
+	})
+}
+
+func Test_AppPathEntries(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	absoluteDir := filepath.Join(t.TempDir(), "tools")
+
+	tests := []struct {
+		name     string
+		entries  []string
+		expected []string
+	}{
+		{
+			name:     "current dir",
+			entries:  []string{"."},
+			expected: []string{currentDir},
+		},
+		{
+			name:     "relative",
+			entries:  []string{"bin", filepath.Join("lib", "bin")},
+			expected: []string{filepath.Join(currentDir, "bin"), filepath.Join(currentDir, "lib", "bin")},
+		},
+		{
+			name:     "absolute",
+			entries:  []string{absoluteDir, "bin"},
+			expected: []string{absoluteDir, filepath.Join(currentDir, "bin")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries := customScoop.AppPathEntries(&scoop.App{
+				Name:       "app",
+				EnvAddPath: test.entries,
+			})
+			require.Equal(t, test.expected, entries)
+		})
+	}
+}
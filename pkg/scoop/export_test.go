@@ -0,0 +1,63 @@
+package scoop
+
+// InvokeInstaller exposes [Installer.invoke] for tests.
+func InvokeInstaller(scoop *Scoop, installer Installer, app *App, dir string, arch ArchitectureKey) error {
+	return installer.invoke(scoop, ScriptHookInstaller, app, dir, arch)
+}
+
+// InvokeUninstaller exposes [Installer.invoke] for uninstallers in tests.
+func InvokeUninstaller(scoop *Scoop, uninstaller Uninstaller, app *App, dir string, arch ArchitectureKey) error {
+	return Installer(uninstaller).invoke(scoop, ScriptHookUninstaller, app, dir, arch)
+}
+
+// SplitCommandLine exposes [splitCommandLine] for tests.
+var SplitCommandLine = splitCommandLine
+
+// Shim dialects exposed for tests.
+var (
+	EscapeShPath         = shDialect.escapePath
+	FormatShArgs         = shDialect.formatArgs
+	FormatCmdArgs        = cmdDialect.formatArgs
+	EscapePowerShellPath = powerShellDialect.escapePath
+)
+
+// RemoveShimsKeeping exposes [Scoop.removeShims] for tests.
+func RemoveShimsKeeping(scoop *Scoop, appName string, keep []Bin, bins ...Bin) error {
+	return scoop.removeShims(appName, keep, bins)
+}
+
+// ReuseSharedVersion exposes [Scoop.reuseSharedVersion] for tests.
+func ReuseSharedVersion(scoop *Scoop, resolvedApp *AppResolved, arch ArchitectureKey, versionDir string) (bool, error) {
+	return scoop.reuseSharedVersion(resolvedApp, arch, versionDir)
+}
+
+// SetAppEnv exposes [Scoop.setAppEnv] for tests.
+func SetAppEnv(scoop *Scoop, appName string, vars []EnvVar, pathEntries []string) error {
+	return scoop.setAppEnv(appName, vars, pathEntries)
+}
+
+// UnsetAppEnv exposes [Scoop.unsetAppEnv] for tests.
+func UnsetAppEnv(scoop *Scoop, appName string, vars []EnvVar, pathEntries []string) error {
+	return scoop.unsetAppEnv(appName, vars, pathEntries)
+}
+
+// SubstituteVariables exposes [manifestVariables.substitute] for tests.
+func SubstituteVariables(value string, variables map[string]string) string {
+	return manifestVariables(variables).substitute(value)
+}
+
+// SubstituteManifestVariables exposes [manifestVariables.substituteManifest]
+// for tests.
+func SubstituteManifestVariables(value string, variables map[string]string) string {
+	return manifestVariables(variables).substituteManifest(value)
+}
+
+// ManifestVariables exposes [Scoop.manifestVariables] for tests.
+func ManifestVariables(scoop *Scoop, app *App, arch ArchitectureKey, dir, versionDir string) map[string]string {
+	return scoop.manifestVariables(app, arch, dir, versionDir)
+}
+
+// PowershellPrelude exposes [manifestVariables.powershellPrelude] for tests.
+func PowershellPrelude(variables map[string]string) []string {
+	return manifestVariables(variables).powershellPrelude()
+}
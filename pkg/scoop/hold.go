@@ -0,0 +1,142 @@
+package scoop
+
+import (
+	stdJson "encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Bios-Marcel/versioncmp"
+)
+
+// HoldDateFormat is the format used for storing [HoldOptions.Until].
+const HoldDateFormat = time.DateOnly
+
+// HoldOptions is additional, optional information that can be stored when
+// holding an app. Scoop doesn't know about these and will simply ignore them.
+type HoldOptions struct {
+	// Reason documents why the app has been held.
+	Reason string
+	// Until is the last day on which the hold is active. Only the date is
+	// relevant, the hold expires at the end of that day in Until's location.
+	Until time.Time
+	// UntilVersion is the version, which, once available, causes the hold to
+	// be considered expired.
+	UntilVersion string
+}
+
+// ParseHoldDate parses a date in [HoldDateFormat] as local time, as the user
+// expects a hold to last until the end of the day on their clock.
+func ParseHoldDate(value string) (time.Time, error) {
+	return time.ParseInLocation(HoldDateFormat, value, time.Local)
+}
+
+// Expired checks whether any of the expiry conditions has been met. If there
+// are no conditions, the hold never expires.
+func (hold HoldOptions) Expired(latestVersion string) bool {
+	if !hold.Until.IsZero() {
+		year, month, day := hold.Until.Date()
+		endOfDay := time.Date(year, month, day+1, 0, 0, 0, 0, hold.Until.Location())
+		if !time.Now().Before(endOfDay) {
+			return true
+		}
+	}
+
+	if hold.UntilVersion != "" && latestVersion != "" {
+		// Compare returns the greater version or an empty string if both are
+		// equal.
+		switch versioncmp.Compare(latestVersion, hold.UntilVersion, versioncmp.VersionCompareRules{}) {
+		case "", latestVersion:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hold marks the installed app as held, preventing updates. Any previously
+// stored [HoldOptions] are replaced.
+func (scoop *Scoop) Hold(appName string, options HoldOptions) error {
+	return scoop.updateInstallJSON(appName, func(installJSON map[string]stdJson.RawMessage) error {
+		installJSON["hold"] = stdJson.RawMessage("true")
+		delete(installJSON, "hold_reason")
+		delete(installJSON, "hold_until")
+		delete(installJSON, "hold_until_version")
+
+		if options.Reason != "" {
+			if err := setJSONString(installJSON, "hold_reason", options.Reason); err != nil {
+				return err
+			}
+		}
+		if !options.Until.IsZero() {
+			if err := setJSONString(installJSON, "hold_until", options.Until.Format(HoldDateFormat)); err != nil {
+				return err
+			}
+		}
+		if options.UntilVersion != "" {
+			if err := setJSONString(installJSON, "hold_until_version", options.UntilVersion); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Unhold removes the hold and all [HoldOptions] from the installed app.
+func (scoop *Scoop) Unhold(appName string) error {
+	return scoop.updateInstallJSON(appName, func(installJSON map[string]stdJson.RawMessage) error {
+		// Scoop removes the field instead of setting it to false.
+		delete(installJSON, "hold")
+		delete(installJSON, "hold_reason")
+		delete(installJSON, "hold_until")
+		delete(installJSON, "hold_until_version")
+		return nil
+	})
+}
+
+func setJSONString(object map[string]stdJson.RawMessage, key, value string) error {
+	encoded, err := stdJson.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding '%s': %w", key, err)
+	}
+	object[key] = encoded
+	return nil
+}
+
+// updateInstallJSON reads the install.json of the currently installed version
+// of the given app and writes it back after applying update. Fields unknown to
+// spoon are preserved.
+func (scoop *Scoop) updateInstallJSON(
+	appName string,
+	update func(installJSON map[string]stdJson.RawMessage) error,
+) error {
+	_, appName, _ = ParseAppIdentifier(appName)
+	path := filepath.Join(scoop.AppDir(), strings.ToLower(appName), "current", "install.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrAppNotInstalled
+		}
+		return fmt.Errorf("error reading install.json: %w", err)
+	}
+
+	installJSON := make(map[string]stdJson.RawMessage)
+	if err := stdJson.Unmarshal(data, &installJSON); err != nil {
+		return fmt.Errorf("error parsing install.json: %w", err)
+	}
+
+	if err := update(installJSON); err != nil {
+		return err
+	}
+
+	data, err = stdJson.MarshalIndent(installJSON, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error encoding install.json: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing install.json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package scoop_test
+
+import (
+	stdJson "encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HoldUnhold(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+	installJSONPath := filepath.Join(currentDir, "install.json")
+	require.NoError(t, os.WriteFile(installJSONPath,
+		[]byte(`{"bucket": "main", "architecture": "64bit", "url": "unknown"}`), 0o600))
+
+	until := time.Date(2030, 1, 2, 0, 0, 0, 0, time.Local)
+	// The app dir is always lowercase, independent of the name passed.
+	require.NoError(t, customScoop.Hold("main/App", scoop.HoldOptions{
+		Reason:       "Breaks our build",
+		Until:        until,
+		UntilVersion: "2.0.0",
+	}))
+
+	app, err := customScoop.FindInstalledApp("app")
+	require.NoError(t, err)
+	require.True(t, app.Hold)
+	require.Equal(t, scoop.ArchitectureKey64Bit, app.Architecture)
+	require.Equal(t, "Breaks our build", app.HoldOptions.Reason)
+	require.Equal(t, until, app.HoldOptions.Until)
+	require.Equal(t, "2.0.0", app.HoldOptions.UntilVersion)
+
+	require.NoError(t, customScoop.Unhold("app"))
+
+	data, err := os.ReadFile(installJSONPath)
+	require.NoError(t, err)
+	installJSON := make(map[string]any)
+	require.NoError(t, stdJson.Unmarshal(data, &installJSON))
+	require.Equal(t, map[string]any{
+		"bucket":       "main",
+		"architecture": "64bit",
+		"url":          "unknown",
+	}, installJSON)
+
+	require.ErrorIs(t, customScoop.Hold("missing", scoop.HoldOptions{}), scoop.ErrAppNotInstalled)
+}
+
+func Test_HoldOptionsExpired(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, scoop.HoldOptions{}.Expired("1.0.0"))
+	require.False(t, scoop.HoldOptions{Reason: "reason"}.Expired("1.0.0"))
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	require.True(t, scoop.HoldOptions{Until: today.AddDate(0, 0, -1)}.Expired(""))
+	// The hold lasts until the end of the given day.
+	require.False(t, scoop.HoldOptions{Until: today}.Expired(""))
+	require.False(t, scoop.HoldOptions{Until: today.AddDate(0, 0, 1)}.Expired(""))
+
+	until, err := scoop.ParseHoldDate(today.Format(scoop.HoldDateFormat))
+	require.NoError(t, err)
+	require.Equal(t, today, until)
+
+	require.False(t, scoop.HoldOptions{UntilVersion: "1.2.0"}.Expired("1.1.9"))
+	require.True(t, scoop.HoldOptions{UntilVersion: "1.2.0"}.Expired("1.2.0"))
+	require.True(t, scoop.HoldOptions{UntilVersion: "1.2.0"}.Expired("1.10.0"))
+	require.False(t, scoop.HoldOptions{UntilVersion: "1.2.0"}.Expired(""))
+}
@@ -0,0 +1,205 @@
+package scoop_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/scooptest"
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InstallUninstall(t *testing.T) {
+	t.Parallel()
+
+	archive := scooptest.ServeZip(t, map[string]string{
+		"tool-1.0.0/bin/tool.exe":     "binary",
+		"tool-1.0.0/data/default.cfg": "config",
+	})
+	manifest := fmt.Sprintf(`{
+    "version": "1.0.0",
+    "url": "%s/tool.zip",
+    "hash": "%s",
+    "extract_dir": "tool-1.0.0",
+    "bin": "bin\\tool.exe",
+    "env_add_path": "bin",
+    "env_set": {
+        "TOOL_HOME": "$dir"
+    },
+    "persist": "data",
+    "shortcuts": [["bin\\tool.exe", "Tool"]],
+    "pre_install": "Write-Host 'pre'",
+    "post_install": ["Write-Host 'post'", "Write-Host $dir"],
+    "uninstaller": {
+        "script": "Write-Host 'uninstall'"
+    }
+}`, archive.URL, archive.Hash)
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	store := scoop.NewMemoryEnvStore(map[string]string{"UNRELATED": "value"})
+	customScoop.EnvStore = store
+	scripts := &scoop.RecordingScriptRunner{}
+	customScoop.ScriptRunner = scripts
+
+	scooptest.WriteManifest(t, customScoop, "tool", manifest)
+
+	appDir := filepath.Join(customScoop.AppDir(), "tool")
+	versionDir := filepath.Join(appDir, "1.0.0")
+	currentDir := filepath.Join(appDir, "current")
+	persistDir := customScoop.AppPersistDir("tool")
+
+	require.NoError(t, customScoop.Install("tool", scoop.ArchitectureKey64Bit))
+
+	isLink, err := windows.IsLink(currentDir)
+	require.NoError(t, err)
+	require.True(t, isLink)
+	require.FileExists(t, filepath.Join(currentDir, "bin", "tool.exe"))
+	require.FileExists(t, filepath.Join(versionDir, "manifest.json"))
+	require.FileExists(t, filepath.Join(customScoop.ShimDir(), "tool.shim"))
+
+	// The default data has been moved into the persist dir and is linked.
+	require.FileExists(t, filepath.Join(persistDir, "data", "default.cfg"))
+	isLink, err = windows.IsLink(filepath.Join(versionDir, "data"))
+	require.NoError(t, err)
+	require.True(t, isLink)
+
+	values, err := store.Values()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"UNRELATED": "value",
+		"TOOL_HOME": currentDir,
+		"Path":      windows.Paths{filepath.Join(currentDir, "bin")}.String(),
+	}, values)
+
+	app, err := customScoop.FindInstalledApp("tool")
+	require.NoError(t, err)
+	require.NotNil(t, app)
+	require.NoError(t, app.LoadDetails(scoop.DetailFieldsAll...))
+	require.Equal(t, scoop.ArchitectureKey64Bit, app.Architecture)
+
+	require.NoError(t, customScoop.Uninstall(app, app.Architecture))
+	require.NoFileExists(t, filepath.Join(customScoop.ShimDir(), "tool.shim"))
+	_, err = os.Lstat(currentDir)
+	require.True(t, os.IsNotExist(err))
+
+	values, err = store.Values()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"UNRELATED": "value"}, values)
+
+	hooks := make([]scoop.ScriptHook, 0, 3)
+	for _, script := range scripts.Scripts() {
+		require.Equal(t, "tool", script.App)
+		hooks = append(hooks, script.Hook)
+	}
+	require.Equal(t, []scoop.ScriptHook{
+		scoop.ScriptHookPreInstall,
+		scoop.ScriptHookPostInstall,
+		scoop.ScriptHookUninstaller,
+	}, hooks)
+	postInstall := scripts.Scripts()[1]
+	require.Equal(t, []string{"Write-Host 'post'", "Write-Host $dir"}, postInstall.Lines)
+	require.Equal(t, currentDir, postInstall.Variables["dir"])
+	require.Equal(t, versionDir, postInstall.Variables["original_dir"])
+
+	// Deleting all versions keeps the persisted data.
+	require.NoError(t, windows.ForceRemoveAll(appDir))
+	require.NoDirExists(t, appDir)
+	require.FileExists(t, filepath.Join(persistDir, "data", "default.cfg"))
+}
+
+func Test_InstallUpdate(t *testing.T) {
+	t.Parallel()
+
+	archive := scooptest.ServeZip(t, map[string]string{
+		"tool.exe":  "binary",
+		"extra.exe": "binary",
+	})
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	customScoop.EnvStore = scoop.NewMemoryEnvStore(nil)
+	customScoop.ScriptRunner = &scoop.RecordingScriptRunner{}
+
+	writeManifest := func(version, bin string) {
+		manifest := fmt.Sprintf(`{
+    "version": "%[2]s",
+    "url": "%[1]s/tool-%[2]s.zip",
+    "hash": "%[3]s",
+    "bin": %[4]s
+}`, archive.URL, version, archive.Hash, bin)
+		scooptest.WriteManifest(t, customScoop, "tool", manifest)
+	}
+
+	writeManifest("1.0.0", `["tool.exe", "extra.exe"]`)
+	require.NoError(t, customScoop.Install("tool", scoop.ArchitectureKey64Bit))
+	require.FileExists(t, filepath.Join(customScoop.ShimDir(), "extra.shim"))
+
+	// The old version's shims are removed, unless the new version provides
+	// them as well.
+	writeManifest("2.0.0", `"tool.exe"`)
+	require.NoError(t, customScoop.Install("tool", scoop.ArchitectureKey64Bit))
+	require.NoFileExists(t, filepath.Join(customScoop.ShimDir(), "extra.shim"))
+
+	shim, err := customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.NotNil(t, shim)
+	require.Equal(t, filepath.Join(customScoop.AppDir(), "tool", "current", "tool.exe"), shim.Target)
+
+	registry, err := customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.Equal(t, "tool", registry.Get("tool").Owner)
+	require.Nil(t, registry.Get("extra"))
+}
+
+func Test_InstallReusesSharedVersion(t *testing.T) {
+	t.Parallel()
+
+	archive := scooptest.ServeZip(t, map[string]string{"tool.exe": "binary"})
+	manifest := fmt.Sprintf(`{
+    "version": "1.0.0",
+    "url": "%s/tool.zip",
+    "hash": "%s",
+    "bin": "tool.exe",
+    "pre_install": "Write-Host 'pre'",
+    "post_install": "Move-Item \"$dir\\tool.exe\" \"$dir\\moved.exe\"",
+    "pre_uninstall": "Remove-Item \"$dir\\tool.exe\""
+}`, archive.URL, archive.Hash)
+
+	newScoop := func(t *testing.T) (*scoop.Scoop, *scoop.RecordingScriptRunner) {
+		customScoop := scoop.NewCustomScoop(t.TempDir())
+		customScoop.EnvStore = scoop.NewMemoryEnvStore(nil)
+		scripts := &scoop.RecordingScriptRunner{}
+		customScoop.ScriptRunner = scripts
+
+		scooptest.WriteManifest(t, customScoop, "tool", manifest)
+		return customScoop, scripts
+	}
+
+	sharedScoop, sharedScripts := newScoop(t)
+	require.NoError(t, sharedScoop.Install("tool", scoop.ArchitectureKey64Bit))
+	require.Len(t, sharedScripts.Scripts(), 2)
+
+	shellScoop, shellScripts := newScoop(t)
+	shellScoop.SharedAppDir = sharedScoop.AppDir()
+	require.NoError(t, shellScoop.Install("tool", scoop.ArchitectureKey64Bit))
+
+	// The files have already been post processed, so none of the scripts
+	// may run again.
+	require.Empty(t, shellScripts.Scripts())
+	sharedInfo, err := os.Stat(filepath.Join(sharedScoop.AppDir(), "tool", "1.0.0", "tool.exe"))
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(shellScoop.AppDir(), "tool", "current", "tool.exe"))
+	require.NoError(t, err)
+	require.True(t, os.SameFile(sharedInfo, info))
+
+	// Uninstall scripts could modify the shared files as well.
+	installedApp, err := shellScoop.FindInstalledApp("tool")
+	require.NoError(t, err)
+	require.True(t, installedApp.Shared)
+	require.NoError(t, installedApp.LoadDetails(scoop.DetailFieldsAll...))
+	require.NoError(t, shellScoop.Uninstall(installedApp, scoop.ArchitectureKey64Bit))
+	require.Empty(t, shellScripts.Scripts())
+	require.FileExists(t, filepath.Join(sharedScoop.AppDir(), "tool", "1.0.0", "tool.exe"))
+}
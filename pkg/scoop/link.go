@@ -0,0 +1,22 @@
+package scoop
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Linker creates directory links, such as the `current` dir of an app and
+// the links into the persist dir. Links are removed like regular files, so
+// only creation differs between platforms.
+type Linker interface {
+	// LinkDir creates a link at link, pointing to the target dir. If
+	// something already exists at link, nothing happens.
+	LinkDir(target, link string) error
+}
+
+// manifestPath converts a relative path from a manifest into a path of the
+// current platform. Manifests are written for Windows, so they usually use
+// backslashes, which aren't separators on other platforms.
+func manifestPath(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
@@ -18,6 +18,8 @@ const (
 	DetailFieldHash          = "hash"
 	DetailFieldArchitecture  = "architecture"
 	DetailFieldDescription   = "description"
+	DetailFieldHomepage      = "homepage"
+	DetailFieldLicense       = "license"
 	DetailFieldVersion       = "version"
 	DetailFieldNotes         = "notes"
 	DetailFieldDepends       = "depends"
@@ -33,6 +35,7 @@ const (
 	DetailFieldInstaller     = "installer"
 	DetailFieldUninstaller   = "uninstaller"
 	DetailFieldInnoSetup     = "innosetup"
+	DetailFieldPSModule      = "psmodule"
 )
 
 // DetailFieldsAll is a list of all available DetailFields to load during
@@ -45,6 +48,8 @@ var DetailFieldsAll = []string{
 	DetailFieldHash,
 	DetailFieldArchitecture,
 	DetailFieldDescription,
+	DetailFieldHomepage,
+	DetailFieldLicense,
 	DetailFieldVersion,
 	DetailFieldNotes,
 	DetailFieldDepends,
@@ -60,6 +65,7 @@ var DetailFieldsAll = []string{
 	DetailFieldInstaller,
 	DetailFieldUninstaller,
 	DetailFieldInnoSetup,
+	DetailFieldPSModule,
 }
 
 // manifestIter gives you an iterator with a big enough size to read any
@@ -136,6 +142,10 @@ func (a *App) loadDetailFromManifestWithIter(
 		switch field {
 		case DetailFieldDescription:
 			a.Description = iter.ReadString()
+		case DetailFieldHomepage:
+			a.Homepage = iter.ReadString()
+		case DetailFieldLicense:
+			a.License = parseLicense(iter)
 		case DetailFieldVersion:
 			a.Version = iter.ReadString()
 		case DetailFieldUrl:
@@ -222,6 +232,14 @@ func (a *App) loadDetailFromManifestWithIter(
 			a.Uninstaller = &uninstaller
 		case DetailFieldInnoSetup:
 			a.InnoSetup = iter.ReadBool()
+		case DetailFieldPSModule:
+			for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
+				if field == "name" {
+					a.PSModule = iter.ReadString()
+				} else {
+					iter.Skip()
+				}
+			}
 		case DetailFieldPreInstall:
 			a.PreInstall = parseStringOrArray(iter)
 		case DetailFieldPostInstall:
@@ -263,6 +281,24 @@ func (a *App) loadDetailFromManifestWithIter(
 	return nil
 }
 
+// parseLicense reads the license identifier, which can either be a string or an
+// object containing the identifier and a URL.
+func parseLicense(iter *jsoniter.Iterator) string {
+	if iter.WhatIsNext() != jsoniter.ObjectValue {
+		return iter.ReadString()
+	}
+
+	var identifier string
+	for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
+		if field == "identifier" {
+			identifier = iter.ReadString()
+		} else {
+			iter.Skip()
+		}
+	}
+	return identifier
+}
+
 func parseInstaller(iter *jsoniter.Iterator) Installer {
 	installer := Installer{}
 	for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
@@ -349,7 +385,7 @@ func parseStringOrArray(iter *jsoniter.Iterator) []string {
 }
 
 func (a App) parseDependency(value string) Dependency {
-	parts := strings.SplitN(value, "/", 1)
+	parts := strings.SplitN(value, "/", 2)
 	switch len(parts) {
 	case 0:
 		// Should be a broken manifest
@@ -0,0 +1,108 @@
+package scoop
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+)
+
+// AppPersistDir is the directory containing the persisted data of the given
+// app. The directory might not exist.
+func (scoop *Scoop) AppPersistDir(appName string) string {
+	return filepath.Join(scoop.PersistDir(), appName)
+}
+
+// BackupPersistData writes a zip archive containing the persisted data of the
+// given app into targetDir. The path of the archive is returned. If the app
+// has no persisted data, no archive is created and an empty path is returned.
+func (scoop *Scoop) BackupPersistData(appName, targetDir string) (string, error) {
+	persistDir := scoop.AppPersistDir(appName)
+	if _, err := os.Stat(persistDir); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error checking persist dir: %w", err)
+	}
+
+	if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("error creating backup dir: %w", err)
+	}
+
+	archivePath := filepath.Join(targetDir,
+		fmt.Sprintf("%s_persist_%s.zip", appName, time.Now().Format("20060102-150405")))
+	archiveFile, err := os.OpenFile(archivePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup archive: %w", err)
+	}
+
+	err = writePersistArchive(archiveFile, persistDir)
+	if closeErr := archiveFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error closing backup archive: %w", closeErr)
+	}
+	if err != nil {
+		// A partial archive would look like a valid backup.
+		os.Remove(archivePath)
+		return "", err
+	}
+	return archivePath, nil
+}
+
+// writePersistArchive writes the contents of persistDir as a zip archive.
+func writePersistArchive(writer io.Writer, persistDir string) error {
+	archive := zip.NewWriter(writer)
+	if err := filepath.WalkDir(persistDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == persistDir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(persistDir, path)
+		if err != nil {
+			return err
+		}
+		// Zip entries always use forward slashes, no matter the OS.
+		name := filepath.ToSlash(relPath)
+		if d.IsDir() {
+			_, err := archive.Create(name + "/")
+			return err
+		}
+
+		source, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		target, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(target, source)
+		return err
+	}); err != nil {
+		return fmt.Errorf("error archiving persist dir: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("error finishing backup archive: %w", err)
+	}
+	return nil
+}
+
+// PurgePersistData deletes all persisted data of the given app. This should
+// only be called after the app has been uninstalled, as the installation links
+// into the persist directory.
+func (scoop *Scoop) PurgePersistData(appName string) error {
+	if err := windows.ForceRemoveAll(scoop.AppPersistDir(appName)); err != nil {
+		return fmt.Errorf("error deleting persist dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package scoop_test
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BackupAndPurgePersistData(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	persistDir := customScoop.AppPersistDir("app")
+	require.NoError(t, os.MkdirAll(filepath.Join(persistDir, "config"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(persistDir, "config", "settings.ini"), []byte("a=b"), 0o600))
+
+	backupDir := t.TempDir()
+	archivePath, err := customScoop.BackupPersistData("app", backupDir)
+	require.NoError(t, err)
+	require.FileExists(t, archivePath)
+
+	archive, err := zip.OpenReader(archivePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { archive.Close() })
+
+	settings, err := archive.Open("config/settings.ini")
+	require.NoError(t, err)
+	content, err := io.ReadAll(settings)
+	require.NoError(t, err)
+	require.Equal(t, "a=b", string(content))
+
+	require.NoError(t, customScoop.PurgePersistData("app"))
+	require.NoDirExists(t, persistDir)
+
+	// Nothing left to back up or purge.
+	archivePath, err = customScoop.BackupPersistData("app", backupDir)
+	require.NoError(t, err)
+	require.Empty(t, archivePath)
+	require.NoError(t, customScoop.PurgePersistData("app"))
+}
+
+func Test_BackupPersistData_Failure(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	persistDir := customScoop.AppPersistDir("app")
+	require.NoError(t, os.MkdirAll(persistDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(persistDir, "a.ini"), []byte("a=b"), 0o600))
+	// A dangling link can't be read, causing the archiving to fail midway.
+	if err := os.Symlink(filepath.Join(persistDir, "missing"), filepath.Join(persistDir, "b.ini")); err != nil {
+		t.Skipf("creating symlinks not possible: %v", err)
+	}
+
+	backupDir := t.TempDir()
+	_, err := customScoop.BackupPersistData("app", backupDir)
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(backupDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,89 @@
+package scoop
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+)
+
+// ProcessRunner is used for invoking external executables, such as installers
+// and uninstallers defined in manifests. This allows replacing the actual
+// process creation, for example in tests.
+type ProcessRunner interface {
+	// Run executes the given executable in the given working directory and
+	// blocks until it has terminated. The returned exit code is only valid if
+	// no error has been returned.
+	Run(workingDir, executable string, args ...string) (int, error)
+}
+
+// ExecProcessRunner is the default [ProcessRunner], which spawns an actual
+// process, connected to the std streams of the current process.
+type ExecProcessRunner struct{}
+
+func (ExecProcessRunner) Run(workingDir, executable string, args ...string) (int, error) {
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = workingDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// RecordedProcess is a process invocation recorded by the
+// [RecordingProcessRunner].
+type RecordedProcess struct {
+	WorkingDir string
+	Executable string
+	Args       []string
+}
+
+// RecordingProcessRunner doesn't spawn any processes, but records them and
+// reports success. This can be used for dry runs, to find out which
+// installers an installation would run.
+type RecordingProcessRunner struct {
+	mutex     sync.Mutex
+	processes []RecordedProcess
+}
+
+func (runner *RecordingProcessRunner) Run(workingDir, executable string, args ...string) (int, error) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	runner.processes = append(runner.processes, RecordedProcess{
+		WorkingDir: workingDir,
+		Executable: executable,
+		Args:       slices.Clone(args),
+	})
+	return 0, nil
+}
+
+// Processes returns all recorded processes in the order they would have run.
+func (runner *RecordingProcessRunner) Processes() []RecordedProcess {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	return slices.Clone(runner.processes)
+}
+
+// ExitCodeError is returned if an installer, uninstaller or script exits with
+// a non-zero exit code.
+type ExitCodeError struct {
+	Executable string
+	Code       int
+}
+
+func (err *ExitCodeError) Error() string {
+	return fmt.Sprintf("'%s' exited with code %d", err.Executable, err.Code)
+}
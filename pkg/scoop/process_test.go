@@ -0,0 +1,115 @@
+package scoop_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+type processCall struct {
+	WorkingDir string
+	Executable string
+	Args       []string
+}
+
+type fakeProcessRunner struct {
+	exitCode int
+	calls    []processCall
+}
+
+func (runner *fakeProcessRunner) Run(workingDir, executable string, args ...string) (int, error) {
+	runner.calls = append(runner.calls, processCall{
+		WorkingDir: workingDir,
+		Executable: executable,
+		Args:       args,
+	})
+	return runner.exitCode, nil
+}
+
+func Test_InvokeInstallerFile(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, exitCode int) (*scoop.Scoop, *fakeProcessRunner, string) {
+		t.Helper()
+
+		root := t.TempDir()
+		runner := &fakeProcessRunner{exitCode: exitCode}
+		customScoop := scoop.NewCustomScoop(root)
+		customScoop.ProcessRunner = runner
+
+		dir := filepath.Join(customScoop.AppDir(), "app", "1.0.0")
+		require.NoError(t, os.MkdirAll(dir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "setup.exe"), nil, 0o600))
+
+		return customScoop, runner, dir
+	}
+	app := &scoop.App{Name: "app", Version: "1.0.0"}
+
+	t.Run("args substituted and file removed", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, runner, dir := setup(t, 0)
+		installer := scoop.Installer{
+			File: "setup.exe",
+			Args: []string{"/S", "/D=$dir", "/DATA=$persist_dir", "/V=$version"},
+		}
+		require.NoError(t, scoop.InvokeInstaller(customScoop, installer, app, dir, scoop.ArchitectureKey64Bit))
+
+		require.Equal(t, []processCall{{
+			WorkingDir: dir,
+			Executable: filepath.Join(dir, "setup.exe"),
+			Args: []string{
+				"/S",
+				"/D=" + dir,
+				"/DATA=" + filepath.Join(customScoop.PersistDir(), "app"),
+				"/V=1.0.0",
+			},
+		}}, runner.calls)
+		require.Equal(t, "/D=$dir", installer.Args[1], "manifest args must not be changed")
+		require.NoFileExists(t, filepath.Join(dir, "setup.exe"))
+	})
+	t.Run("keep", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, runner, dir := setup(t, 0)
+		installer := scoop.Installer{File: "setup.exe", Keep: true}
+		require.NoError(t, scoop.InvokeInstaller(customScoop, installer, app, dir, scoop.ArchitectureKey64Bit))
+
+		require.Len(t, runner.calls, 1)
+		require.FileExists(t, filepath.Join(dir, "setup.exe"))
+	})
+	t.Run("uninstaller not removed", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, runner, dir := setup(t, 0)
+		uninstaller := scoop.Uninstaller{File: "setup.exe", Args: []string{"/uninstall"}}
+		require.NoError(t, scoop.InvokeUninstaller(customScoop, uninstaller, app, dir, scoop.ArchitectureKey64Bit))
+
+		require.Len(t, runner.calls, 1)
+		require.FileExists(t, filepath.Join(dir, "setup.exe"))
+	})
+	t.Run("non-zero exit code", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, _, dir := setup(t, 1603)
+		installer := scoop.Installer{File: "setup.exe"}
+		err := scoop.InvokeInstaller(customScoop, installer, app, dir, scoop.ArchitectureKey64Bit)
+
+		var exitCodeErr *scoop.ExitCodeError
+		require.ErrorAs(t, err, &exitCodeErr)
+		require.Equal(t, 1603, exitCodeErr.Code)
+		// We don't delete, so the user can investigate.
+		require.FileExists(t, filepath.Join(dir, "setup.exe"))
+	})
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		customScoop, runner, dir := setup(t, 0)
+		installer := scoop.Installer{File: "missing.exe"}
+		require.Error(t, scoop.InvokeInstaller(customScoop, installer, app, dir, scoop.ArchitectureKey64Bit))
+		require.Empty(t, runner.calls)
+	})
+}
@@ -9,11 +9,11 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
-	stdJson "encoding/json"
 	"errors"
 	"fmt"
 	"hash"
 	"io"
+	"io/fs"
 	"math"
 	"os"
 	"os/exec"
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Bios-Marcel/spoon/internal/git"
 	"github.com/Bios-Marcel/spoon/internal/json"
@@ -156,12 +157,25 @@ func (scoop *Scoop) findInstalledApp(iter *jsoniter.Iterator, name string) (*Ins
 	}
 	defer installJson.Close()
 
+	// Scoop doesn't persist the installation time. The install.json is
+	// rewritten when holding apps, but the manifest.json is only written on
+	// installation. Old installations might lack it though.
+	installInfo, err := os.Stat(filepath.Join(appDir, "manifest.json"))
+	if err != nil {
+		installInfo, err = installJson.Stat()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error determining installation date: %w", err)
+	}
+
 	json.Reset(iter, installJson)
 
 	var (
 		bucketName   string
 		architecture string
 		hold         bool
+		holdOptions  HoldOptions
+		shared       bool
 	)
 	for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
 		switch field {
@@ -171,6 +185,16 @@ func (scoop *Scoop) findInstalledApp(iter *jsoniter.Iterator, name string) (*Ins
 			bucketName = iter.ReadString()
 		case "hold":
 			hold = iter.ReadBool()
+		case "hold_reason":
+			holdOptions.Reason = iter.ReadString()
+		case "hold_until":
+			// An invalid date is treated as no date, as we don't want to
+			// break reading the installation because of optional metadata.
+			holdOptions.Until, _ = ParseHoldDate(iter.ReadString())
+		case "hold_until_version":
+			holdOptions.UntilVersion = iter.ReadString()
+		case "shared":
+			shared = iter.ReadBool()
 		default:
 			iter.Skip()
 		}
@@ -183,7 +207,10 @@ func (scoop *Scoop) findInstalledApp(iter *jsoniter.Iterator, name string) (*Ins
 
 	return &InstalledApp{
 		Hold:         hold,
+		HoldOptions:  holdOptions,
 		Architecture: ArchitectureKey(architecture),
+		InstallDate:  installInfo.ModTime(),
+		Shared:       shared,
 		App: &App{
 			Bucket:       bucket,
 			Name:         name,
@@ -283,6 +310,8 @@ func (scoop *Scoop) GetLocalBuckets() ([]*Bucket, error) {
 type App struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+	License     string `json:"license"`
 	Version     string `json:"version"`
 	Notes       string `json:"notes"`
 
@@ -305,6 +334,9 @@ type App struct {
 	PreUninstall  []string     `json:"pre_uninstall"`
 	PostUninstall []string     `json:"post_uninstall"`
 	ExtractTo     []string     `json:"extract_to"`
+	// PSModule is the name of the PowerShell module the app provides. Scoop
+	// makes it available to all PowerShell sessions.
+	PSModule string `json:"psmodule"`
 
 	// Spoon "internals"
 
@@ -317,9 +349,17 @@ type InstalledApp struct {
 	// Hold indicates whether the app should be kept on the currently installed
 	// version. It's versioning pinning.
 	Hold bool
+	// HoldOptions are only relevant if Hold is set.
+	HoldOptions HoldOptions
 	// Archictecture defines which architecture was used for installation. On a
 	// 64Bit system for example, this could also be 32Bit, but not vice versa.
 	Architecture ArchitectureKey
+	// InstallDate is the point in time at which the current version was
+	// installed.
+	InstallDate time.Time
+	// Shared indicates that the files are hardlinks to the installation in
+	// [Scoop.SharedAppDir]. See [Scoop.reuseSharedVersion].
+	Shared bool
 }
 
 type OutdatedApp struct {
@@ -413,42 +453,58 @@ type Installer struct {
 type Uninstaller Installer
 
 // invoke will run the installer script or file. This method is implemented on a
-// non-pointer as we manipulate the script.
-func (installer Installer) invoke(scoop *Scoop, dir string, arch ArchitectureKey) error {
+// non-pointer as we manipulate the script. The hook identifies whether this is
+// an installer or uninstaller.
+func (installer Installer) invoke(scoop *Scoop, hook ScriptHook, app *App, dir string, arch ArchitectureKey) error {
 	// File and Script are mutually exclusive and Keep is only used if script is
 	// not set. However, we automatically set file to the last downloaded file
 	// if none is set, we then pass this to the script if any is present.
+	// FIXME We don't intend to support writing back the manifest into our
+	// context for now, as it seems only 1 or 2 apps actually do this. Instead,
+	// we should try to prepend a line that parses the manifest inline and
+	// creates the $manifest variable locally.
+	variables := scoop.manifestVariables(app, arch, dir, dir).with("fname", installer.File)
 	if len(installer.Script) > 0 {
-		variableSubstitutions := map[string]string{
-			"$fname":        installer.File,
-			"$dir":          dir,
-			"$architecture": string(arch),
-			// FIXME We don't intend to support writing back the manifest into
-			// our context for now, as it seems only 1 or 2 apps actually do
-			// this. Instead, we should try to prepend a line that parses the
-			// manifest inline and creates the variable locally.
-			"$manifest": "TODO",
-		}
-		for index, line := range installer.Script {
-			installer.Script[index] = substituteVariables(line, variableSubstitutions)
-		}
-		if err := scoop.runScript(installer.Script); err != nil {
+		if err := scoop.runScript(hook, app, installer.Script, variables); err != nil {
 			return fmt.Errorf("error running installer: %w", err)
 		}
 	} else if installer.File != "" {
-		// FIXME RUN! Not extract?
+		// FIXME Okay ... it seems scoop downloads the files not only into
+		// cache, but also into the installation directory. This seems a bit
+		// wasteful to me. Instead, we should copy the files into the dir
+		// only if we actually want to keep them. This way we can prevent
+		// useless copy and remove actions.
+		path := filepath.Join(dir, manifestPath(installer.File))
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("error locating installer file: %w", err)
+		}
+
+		// We copy, as we'd otherwise manipulate the args of the app.
+		args := make([]string, len(installer.Args))
+		for index, arg := range installer.Args {
+			args[index] = variables.substitute(arg)
+		}
+
+		executable := path
+		if strings.EqualFold(filepath.Ext(path), ".ps1") {
+			executable = "powershell.exe"
+			args = append([]string{"-NoLogo", "-NoProfile", "-File", path}, args...)
+		}
+
+		exitCode, err := scoop.ProcessRunner.Run(dir, executable, args...)
+		if err != nil {
+			return fmt.Errorf("error running installer file: %w", err)
+		}
+		if exitCode != 0 {
+			return &ExitCodeError{Executable: installer.File, Code: exitCode}
+		}
 
-		if !installer.Keep {
-			// FIXME Okay ... it seems scoop downloads the files not only into
-			// cache, but also into the installation directory. This seems a bit
-			// wasteful to me. Instead, we should copy the files into the dir
-			// only if we actually want to keep them. This way we can prevent
-			// useless copy and remove actions.
-			//
-			// This implementation shouldn't be part of the download, but
-			// instead be done during installation, manually checking both
-			// uninstaller.keep and installer.keep, copying if necessary and
-			// correctly invoking with the resulting paths.
+		// Uninstallers are left alone, as they often remove themselves and
+		// the version dir is deleted afterwards anyway.
+		if hook == ScriptHookInstaller && !installer.Keep {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("error removing installer file: %w", err)
+			}
 		}
 	}
 
@@ -574,37 +630,122 @@ func (scoop *Scoop) GetOutdatedApps() ([]*OutdatedApp, error) {
 	return outdated, nil
 }
 
+// InstalledApps returns all apps installed in this scoop instance, including
+// the information found in their install.json. The manifests are not loaded,
+// you need to call [App.LoadDetails] on each one.
 func (scoop *Scoop) InstalledApps() ([]*InstalledApp, error) {
 	manifestPaths, err := filepath.Glob(filepath.Join(scoop.AppDir(), "*/current/manifest.json"))
 	if err != nil {
 		return nil, fmt.Errorf("error globbing manifests: %w", err)
 	}
 
-	apps := make([]*InstalledApp, len(manifestPaths))
-	for index, manifestPath := range manifestPaths {
+	iter := jsoniter.Parse(jsoniter.ConfigFastest, nil, 256)
+	apps := make([]*InstalledApp, 0, len(manifestPaths))
+	for _, manifestPath := range manifestPaths {
 		// FIXME Check if installation stems from correct bucket!
-		installJson := make(map[string]any)
-		bytes, err := os.ReadFile(filepath.Join(filepath.Dir(manifestPath), "install.json"))
+		name := filepath.Base(filepath.Dir(filepath.Dir(manifestPath)))
+		app, err := scoop.findInstalledApp(iter, name)
 		if err != nil {
-			return nil, fmt.Errorf("error reading install.json: %w", err)
+			return nil, fmt.Errorf("error reading installation of '%s': %w", name, err)
 		}
-		if err := stdJson.Unmarshal(bytes, &installJson); err != nil {
-			return nil, fmt.Errorf("error unmarshalling: %w", err)
+		if app == nil {
+			return nil, fmt.Errorf("error reading install.json of '%s': %w", name, os.ErrNotExist)
 		}
+		apps = append(apps, app)
+	}
 
-		var bucket *Bucket
-		bucketName := installJson["bucket"]
-		if bucketNameStr, ok := bucketName.(string); ok {
-			bucket = scoop.GetBucket(bucketNameStr)
+	return apps, nil
+}
+
+// InstalledVersions returns all versions of the given app, that are still
+// present on disk. This includes the current version.
+func (scoop *Scoop) InstalledVersions(appName string) ([]string, error) {
+	names, err := windows.GetDirFilenames(filepath.Join(scoop.AppDir(), appName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
-		apps[index] = &InstalledApp{App: &App{
-			Bucket:       bucket,
-			Name:         strings.TrimSuffix(filepath.Base(filepath.Dir(filepath.Dir(manifestPath))), ".json"),
-			manifestPath: manifestPath,
-		}}
+		return nil, fmt.Errorf("error reading installed versions: %w", err)
 	}
 
-	return apps, nil
+	versions := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "current" {
+			versions = append(versions, name)
+		}
+	}
+	return versions, nil
+}
+
+// FindOwningApp determines which installed app the given path belongs to. The
+// returned values are the app name and the version. If the path points into
+// the `current` directory, the version is resolved. If the path isn't part of
+// any installation, empty strings are returned.
+func (scoop *Scoop) FindOwningApp(path string) (string, string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", fmt.Errorf("error determining absolute path: %w", err)
+	}
+	appDir, err := filepath.Abs(scoop.AppDir())
+	if err != nil {
+		return "", "", fmt.Errorf("error determining absolute path: %w", err)
+	}
+
+	// Windows paths are case insensitive.
+	prefix := appDir + string(filepath.Separator)
+	if len(path) <= len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return "", "", nil
+	}
+
+	parts := strings.SplitN(filepath.ToSlash(path[len(prefix):]), "/", 3)
+	appName := strings.ToLower(parts[0])
+	if len(parts) < 2 {
+		return appName, "", nil
+	}
+
+	version := parts[1]
+	if strings.EqualFold(version, "current") {
+		app, err := scoop.FindInstalledApp(appName)
+		if err != nil {
+			return "", "", fmt.Errorf("error finding installed app: %w", err)
+		}
+		if app == nil {
+			return appName, "", nil
+		}
+		if err := app.LoadDetails(DetailFieldVersion); err != nil {
+			return "", "", fmt.Errorf("error loading installed version: %w", err)
+		}
+		version = app.Version
+	}
+
+	return appName, version, nil
+}
+
+// AppSize calculates the disk usage of all installed versions of the given
+// app. Linked directories, such as persisted data, aren't followed.
+func (scoop *Scoop) AppSize(appName string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(filepath.Join(scoop.AppDir(), appName), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Junctions and symlinks aren't reported as directories, so we
+		// have to make sure not to count them as files either.
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error calculating app size: %w", err)
+	}
+	return size, nil
 }
 
 func (scoop *Scoop) BucketDir() string {
@@ -635,18 +776,20 @@ func GetDefaultScoopDir() (string, error) {
 	return filepath.Join(home, "scoop"), nil
 }
 
-func (scoop *Scoop) runScript(lines []string) error {
-	// To slash, so we don't have to escape
-	bucketsDir := `"` + filepath.ToSlash(scoop.BucketDir()) + `"`
-
-	substitutedLines := make([]string, len(lines))
-	for index, line := range lines {
-		substitutedLines[index] = substituteVariables(line, map[string]string{
-			"$bucketsdir": bucketsDir,
-		})
+// runScript runs the given manifest script using the [Scoop.ScriptRunner].
+// The variables are defined at the start of the script.
+func (scoop *Scoop) runScript(hook ScriptHook, app *App, lines []string, variables manifestVariables) error {
+	// Prevent unnecessary process creation
+	if len(lines) == 0 {
+		return nil
 	}
 
-	return windows.RunPowershellScript(substitutedLines, true)
+	return scoop.ScriptRunner.Run(&Script{
+		Hook:      hook,
+		App:       app.Name,
+		Lines:     lines,
+		Variables: variables,
+	})
 }
 
 // InstallAll will install the given application into userspace. If an app is
@@ -850,36 +993,39 @@ func (scoop *Scoop) Install(appName string, arch ArchitectureKey) error {
 }
 
 func (scoop *Scoop) Uninstall(app *InstalledApp, arch ArchitectureKey) error {
+	return scoop.uninstall(app, arch, nil)
+}
+
+// uninstall is [Scoop.Uninstall], but keeps the shims provided by keepBins,
+// which is used when updating to a version providing these.
+func (scoop *Scoop) uninstall(app *InstalledApp, arch ArchitectureKey, keepBins []Bin) error {
 	resolvedApp := app.ForArch(arch)
+	// Shared files belong to the installation in the shared scoop, so
+	// uninstall scripts could break it by modifying them in place. Neither
+	// did the installation itself run any scripts.
+	runScripts := !app.Shared
 
-	if err := scoop.runScript(resolvedApp.PreUninstall); err != nil {
-		return fmt.Errorf("error executing pre_uninstall script: %w", err)
+	versionDir := filepath.Join(scoop.AppDir(), app.Name, app.Version)
+	variables := scoop.manifestVariables(app.App, arch, versionDir, versionDir)
+	if runScripts {
+		if err := scoop.runScript(ScriptHookPreUninstall, app.App, resolvedApp.PreUninstall, variables); err != nil {
+			return fmt.Errorf("error executing pre_uninstall script: %w", err)
+		}
 	}
 
-	if uninstaller := resolvedApp.Uninstaller; uninstaller != nil {
-		dir := filepath.Join(scoop.AppDir(), app.Name, app.Version)
-		if err := Installer(*uninstaller).invoke(scoop, dir, arch); err != nil {
+	if uninstaller := resolvedApp.Uninstaller; uninstaller != nil && runScripts {
+		if err := Installer(*uninstaller).invoke(scoop, ScriptHookUninstaller, app.App, versionDir, arch); err != nil {
 			return fmt.Errorf("error invoking uninstaller: %w", err)
 		}
 	}
 
-	var updatedEnvVars [][2]string
-	for _, envVar := range resolvedApp.EnvSet {
-		updatedEnvVars = append(updatedEnvVars, [2]string{envVar.Key, ""})
-	}
-
-	if len(resolvedApp.EnvAddPath) > 0 {
-		pathKey, pathVar, err := windows.GetPersistentEnvValue("User")
-		if err != nil {
-			return fmt.Errorf("error retrieving path variable: %w", err)
+	if !scoop.Isolated {
+		// Older versions added the path entries without resolving them, so
+		// they are removed as well for apps missing in the env ledger.
+		pathEntries := append(scoop.AppPathEntries(app.App), resolvedApp.EnvAddPath...)
+		if err := scoop.unsetAppEnv(app.Name, resolvedApp.EnvSet, pathEntries); err != nil {
+			return err
 		}
-
-		newPath := windows.ParsePath(pathVar).Remove(resolvedApp.EnvAddPath...)
-		updatedEnvVars = append(updatedEnvVars, [2]string{pathKey, newPath.String()})
-	}
-
-	if err := windows.SetPersistentEnvValues(updatedEnvVars...); err != nil {
-		return fmt.Errorf("error restoring environment variables: %w", err)
 	}
 
 	appDir := filepath.Join(scoop.AppDir(), app.Name)
@@ -891,23 +1037,29 @@ func (scoop *Scoop) Uninstall(app *InstalledApp, arch ArchitectureKey) error {
 		return fmt.Errorf("error deleting installation files: %w", err)
 	}
 
-	if err := scoop.RemoveShims(resolvedApp.Bin...); err != nil {
+	if err := scoop.removeShims(app.Name, keepBins, resolvedApp.Bin); err != nil {
 		return fmt.Errorf("error removing shim: %w", err)
 	}
 
 	// FIXME Should we instead manually delete all .lnk files and then check for
 	// leftover empty directories? This could be relevant if there are some dirs
 	// that share a shortcut subdirectory.
-	if len(resolvedApp.Shortcuts) > 0 {
+	if len(resolvedApp.Shortcuts) > 0 && !scoop.Isolated {
 		startmenuPath, err := scoop.ShortcutDir()
-		if err != nil {
+		if err != nil && !errors.Is(err, errors.ErrUnsupported) {
 			return err
 		}
 
 		for _, shortcut := range resolvedApp.Shortcuts {
-			dir := filepath.Dir(shortcut.ShortcutName)
+			// Shortcuts are never created on unsupported platforms.
+			if startmenuPath == "" {
+				break
+			}
+
+			shortcutName := manifestPath(shortcut.ShortcutName)
+			dir := filepath.Dir(shortcutName)
 			if dir == "." {
-				if err := os.Remove(filepath.Join(startmenuPath, shortcut.ShortcutName+".lnk")); err != nil {
+				if err := os.Remove(filepath.Join(startmenuPath, shortcutName+".lnk")); err != nil && !os.IsNotExist(err) {
 					return fmt.Errorf("error deleting shortcut: %w", err)
 				}
 				continue
@@ -919,8 +1071,10 @@ func (scoop *Scoop) Uninstall(app *InstalledApp, arch ArchitectureKey) error {
 		}
 	}
 
-	if err := scoop.runScript(resolvedApp.PostUninstall); err != nil {
-		return fmt.Errorf("error executing post_uninstall script: %w", err)
+	if runScripts {
+		if err := scoop.runScript(ScriptHookPostUninstall, app.App, resolvedApp.PostUninstall, variables); err != nil {
+			return fmt.Errorf("error executing post_uninstall script: %w", err)
+		}
 	}
 	return nil
 }
@@ -928,7 +1082,9 @@ func (scoop *Scoop) Uninstall(app *InstalledApp, arch ArchitectureKey) error {
 var (
 	ErrAlreadyInstalled         = errors.New("app already installed (same version)")
 	ErrAppNotFound              = errors.New("app not found")
+	ErrAppNotInstalled          = errors.New("app not installed")
 	ErrAppNotAvailableInVersion = errors.New("app not available in desird version")
+	ErrShimNotFound             = errors.New("shim not found")
 )
 
 func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch ArchitectureKey) error {
@@ -996,17 +1152,23 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 		defer closer.Close()
 	}
 
+	if scoop.AuditPolicy != nil {
+		if blocked := scoop.AuditPolicy.Blocked(scoop.Audit(app, arch)); len(blocked) > 0 {
+			return &AuditBlockedError{App: app.Name, Findings: blocked}
+		}
+	}
+
 	// We reuse the handle.
 	if _, err := manifestFile.Seek(0, 0); err != nil {
 		return fmt.Errorf("error resetting manifest file handle: %w", err)
 	}
 
+	resolvedApp := app.ForArch(arch)
+
 	if installedApp != nil {
-		if err := installedApp.LoadDetailsWithIter(iter,
-			DetailFieldVersion,
-			DetailFieldPreUninstall,
-			DetailFieldPostUninstall,
-		); err != nil {
+		// Uninstalling requires everything the installation set up, such as
+		// shims and environment variables.
+		if err := installedApp.LoadDetailsWithIter(iter, DetailFieldsAll...); err != nil {
 			return fmt.Errorf("error determining installed version: %w", err)
 		}
 
@@ -1016,8 +1178,9 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 		}
 
 		// We use the installedApp Architecture, as it doesn't necessarily has
-		// to match with the desired arch.
-		if err := scoop.Uninstall(installedApp, installedApp.Architecture); err != nil {
+		// to match with the desired arch. The shims of the new version are
+		// kept, as they'd otherwise be handed to other apps providing them.
+		if err := scoop.uninstall(installedApp, installedApp.Architecture, resolvedApp.Bin); err != nil {
 			return fmt.Errorf("error uninstalling exiting version: %w", err)
 		}
 	}
@@ -1025,13 +1188,87 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 	// FIXME Check if an old version is already installed and we can
 	// just-relink it.
 
-	resolvedApp := app.ForArch(arch)
+	versionDir := filepath.Join(scoop.AppDir(), app.Name, app.Version)
+	reused, err := scoop.reuseSharedVersion(resolvedApp, arch, versionDir)
+	if err != nil {
+		return err
+	}
+	// The scripts and the installer shape the installation files, so they
+	// have already been run for reused installations. The same goes for
+	// the post install script further down.
+	if !reused {
+		if err := scoop.unpack(app, resolvedApp, versionDir, arch); err != nil {
+			return err
+		}
+	}
 
-	scoop.runScript(resolvedApp.PreInstall)
+	// FIXME Make copy util?
+	// FIXME Read perms?
+	newManifestFile, err := os.OpenFile(
+		filepath.Join(versionDir, "manifest.json"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating new manifest: %w", err)
+	}
+	_, err = io.Copy(newManifestFile, manifestFile)
+	newManifestFile.Close()
+	if err != nil {
+		return fmt.Errorf("error copying manfiest: %w", err)
+	}
 
-	appDir := filepath.Join(scoop.AppDir(), app.Name)
-	versionDir := filepath.Join(appDir, app.Version)
-	if err := os.MkdirAll(versionDir, os.ModeDir); err != nil {
+	// FIXME Adjust arch value if we install anything else than is desired.
+	if err := os.WriteFile(filepath.Join(versionDir, "install.json"), []byte(fmt.Sprintf(
+		`{
+    "bucket": "%s",
+    "architecture": "%s",
+    "hold": %v,
+    "shared": %v
+}`, app.Bucket.Name(), arch, version != "", reused)), 0o600); err != nil {
+		return fmt.Errorf("error writing installation information: %w", err)
+	}
+
+	fmt.Println("Linking to newly installed version.")
+	if err := scoop.link(resolvedApp, arch, versionDir); err != nil {
+		return err
+	}
+
+	// Reused files have already been post processed. Running the script
+	// again would fail for scripts that move files and would modify the
+	// shared files for scripts editing them in place.
+	if reused {
+		return nil
+	}
+
+	currentDir := filepath.Join(scoop.AppDir(), app.Name, "current")
+	if err := scoop.runScript(
+		ScriptHookPostInstall,
+		app,
+		resolvedApp.PostInstall,
+		scoop.manifestVariables(app, arch, currentDir, versionDir),
+	); err != nil {
+		return fmt.Errorf("error running post install script: %w", err)
+	}
+
+	return nil
+}
+
+// unpack downloads and extracts the files of the app into versionDir and runs
+// the pre install script and the installer.
+func (scoop *Scoop) unpack(
+	app *App,
+	resolvedApp *AppResolved,
+	versionDir string,
+	arch ArchitectureKey,
+) error {
+	if err := scoop.runScript(
+		ScriptHookPreInstall,
+		app,
+		resolvedApp.PreInstall,
+		scoop.manifestVariables(app, arch, versionDir, versionDir),
+	); err != nil {
+		return fmt.Errorf("error running pre install script: %w", err)
+	}
+
+	if err := os.MkdirAll(versionDir, os.ModePerm); err != nil {
 		return fmt.Errorf("error creating installation target dir: %w", err)
 	}
 
@@ -1059,96 +1296,100 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 	}
 
 	if installer := resolvedApp.Installer; installer != nil {
-		dir := filepath.Join(scoop.AppDir(), app.Name, app.Version)
-		if err := installer.invoke(scoop, dir, arch); err != nil {
+		if err := installer.invoke(scoop, ScriptHookInstaller, app, versionDir, arch); err != nil {
 			return fmt.Errorf("error invoking installer: %w", err)
 		}
 	}
 
-	// FIXME Make copy util?
-	// FIXME Read perms?
-	newManifestFile, err := os.OpenFile(
-		filepath.Join(versionDir, "manifest.json"), os.O_CREATE|os.O_TRUNC, 0o600)
-	if err != nil {
-		return fmt.Errorf("error creating new manifest: %w", err)
+	return nil
+}
+
+// Reset re-creates everything an installation consists of, apart from the
+// installation files themselves. This includes the `current` link, shims,
+// environment variables, shortcuts and persist links. This is useful if
+// another app has overwritten shims or the environment has been tampered
+// with.
+func (scoop *Scoop) Reset(app *InstalledApp) error {
+	if err := app.LoadDetails(DetailFieldsAll...); err != nil {
+		return fmt.Errorf("error loading installed manifest: %w", err)
 	}
-	if _, err := io.Copy(newManifestFile, manifestFile); err != nil {
-		return fmt.Errorf("error copying manfiest: %w", err)
+
+	versionDir := filepath.Join(scoop.AppDir(), app.Name, app.Version)
+	if _, err := os.Stat(versionDir); err != nil {
+		return fmt.Errorf("error checking installation dir: %w", err)
 	}
 
-	fmt.Println("Linking to newly installed version.")
+	// If current isn't a link, it contains the actual installation, so we
+	// can't touch it.
+	currentDir := filepath.Join(scoop.AppDir(), app.Name, "current")
+	isLink, err := windows.IsLink(currentDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error checking current dir: %w", err)
+	}
+	if isLink {
+		if err := os.Remove(currentDir); err != nil {
+			return fmt.Errorf("error unlinking current dir: %w", err)
+		}
+	}
+
+	return scoop.link(app.ForArch(app.Architecture), app.Architecture, versionDir)
+}
+
+// link makes the installation in versionDir available to the user. It creates
+// the `current` link, shims, shortcuts, persist links and sets environment
+// variables. All steps can be repeated, so this is used for both installation
+// and resetting. Shortcuts and environment variables are skipped for
+// [Scoop.Isolated] installations.
+func (scoop *Scoop) link(resolvedApp *AppResolved, arch ArchitectureKey, versionDir string) error {
+	appDir := filepath.Dir(versionDir)
 	currentDir := filepath.Join(appDir, "current")
-	if err := windows.CreateJunctions([2]string{versionDir, currentDir}); err != nil {
+	if err := scoop.Linker.LinkDir(versionDir, currentDir); err != nil {
 		return fmt.Errorf("error linking from new current dir: %w", err)
 	}
 
+	shimRegistry, err := scoop.LoadShimRegistry()
+	if err != nil {
+		return err
+	}
+
+	persistDir := scoop.AppPersistDir(resolvedApp.Name)
+
 	// Shims are copies of a certain binary that uses a ".shim" file next to
 	// it to realise some type of symlink.
 	for _, bin := range resolvedApp.Bin {
-		fmt.Printf("Creating shim for '%s'\n", bin.Name)
-		if err := scoop.CreateShim(filepath.Join(currentDir, bin.Name), bin); err != nil {
-			return fmt.Errorf("error creating shim: %w", err)
+		shimName := bin.ShimName()
+		if registration := shimRegistry.Get(shimName); registration != nil && registration.User {
+			fmt.Printf("Skipping shim '%s', as it has been added manually\n", shimName)
+			continue
 		}
-	}
 
-	var envVars [][2]string
-	if len(resolvedApp.EnvAddPath) > 0 {
-		pathKey, oldPath, err := windows.GetPersistentEnvValue("Path")
+		owner, err := scoop.shimOwner(shimRegistry, shimName)
 		if err != nil {
-			return fmt.Errorf("error attempt to add variables to path: %w", err)
+			return fmt.Errorf("error determining shim owner: %w", err)
+		}
+		if owner != "" && !strings.EqualFold(owner, resolvedApp.Name) {
+			fmt.Printf("Warning: Overwriting shim '%s' provided by '%s'\n", shimName, owner)
 		}
-		parsedPath := windows.ParsePath(oldPath).Prepend(resolvedApp.EnvAddPath...)
-		envVars = append(envVars, [2]string{pathKey, parsedPath.String()})
-	}
-
-	persistDir := filepath.Join(scoop.PersistDir(), app.Name)
-	for _, pathEntry := range resolvedApp.EnvSet {
-		value := substituteVariables(pathEntry.Value, map[string]string{
-			"dir":         currentDir,
-			"persist_dir": persistDir,
-		})
-		envVars = append(envVars, [2]string{pathEntry.Key, value})
-	}
-
-	if err := windows.SetPersistentEnvValues(envVars...); err != nil {
-		return fmt.Errorf("error setting env values: %w", err)
-	}
-
-	// FIXME Adjust arch value if we install anything else than is desired.
-	if err := os.WriteFile(filepath.Join(versionDir, "install.json"), []byte(fmt.Sprintf(
-		`{
-    "bucket": "%s",
-    "architecture": "%s",
-    "hold": %v
-}`, app.Bucket.Name(), arch, version != "")), 0o600); err != nil {
-		return fmt.Errorf("error writing installation information: %w", err)
-	}
 
-	if len(resolvedApp.Shortcuts) > 0 {
-		startmenuPath, err := scoop.ShortcutDir()
-		if err != nil {
+		// The existing shim might be of a different type, which would leave
+		// behind files taking precedence over the new shim.
+		if err := scoop.deleteShimFiles(shimName); err != nil {
 			return err
 		}
 
-		var winShortcuts []windows.Shortcut
-		for _, shortcut := range resolvedApp.Shortcuts {
-			var winShortcut windows.Shortcut
-			winShortcut.Dir = filepath.Join(startmenuPath, filepath.Dir(shortcut.ShortcutName))
-			winShortcut.LinkTarget = filepath.Join(currentDir, shortcut.Name)
-			winShortcut.Alias = filepath.Base(shortcut.ShortcutName)
-			if shortcut.Icon != "" {
-				winShortcut.Icon = filepath.Join(currentDir, shortcut.Icon)
-			}
-			winShortcut.Args = substituteVariables(shortcut.Args, map[string]string{
-				"dir":          currentDir,
-				"original_dir": versionDir,
-				"persist_dir":  persistDir,
-			})
-			winShortcuts = append(winShortcuts, winShortcut)
+		fmt.Printf("Creating shim for '%s'\n", bin.Name)
+		if err := scoop.createAppShim(resolvedApp.App, arch, bin); err != nil {
+			return err
 		}
+		shimRegistry.Set(shimName, &ShimRegistration{Owner: resolvedApp.Name})
+	}
+	if err := scoop.SaveShimRegistry(shimRegistry); err != nil {
+		return err
+	}
 
-		if err := windows.CreateShortcuts(winShortcuts...); err != nil {
-			return fmt.Errorf("error creating shortcuts: %w", err)
+	if !scoop.Isolated {
+		if err := scoop.linkEnvironment(resolvedApp, arch, versionDir); err != nil {
+			return err
 		}
 	}
 
@@ -1158,19 +1399,19 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 		// been touched for at least 5 years. Either this was a scoop feature at
 		// some point or it never was and went uncaught.
 
-		source := filepath.Join(versionDir, entry.Dir)
+		source := filepath.Join(versionDir, manifestPath(entry.Dir))
 		var target string
 		if entry.LinkName != "" {
-			target = filepath.Join(persistDir, entry.LinkName)
+			target = filepath.Join(persistDir, manifestPath(entry.LinkName))
 		} else {
-			target = filepath.Join(persistDir, entry.Dir)
+			target = filepath.Join(persistDir, manifestPath(entry.Dir))
 		}
 
-		_, targetErr := os.Stat(target)
+		targetInfo, targetErr := os.Stat(target)
 		if targetErr != nil && !os.IsNotExist(targetErr) {
 			return targetErr
 		}
-		_, sourceErr := os.Stat(source)
+		sourceInfo, sourceErr := os.Stat(source)
 		if sourceErr != nil && !os.IsNotExist(sourceErr) {
 			return sourceErr
 		}
@@ -1178,17 +1419,25 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 		// Target exists
 		if targetErr == nil {
 			if sourceErr == nil {
+				// When resetting, the link might already be in place.
+				if os.SameFile(sourceInfo, targetInfo) {
+					continue
+				}
+
 				// "Backup" the source. Scoop did this as well.
 				if err := os.Rename(source, source+".original"); err != nil {
 					return fmt.Errorf("error backing up source: %w", err)
 				}
 			}
 		} else if sourceErr == nil {
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return fmt.Errorf("error creating persist dir: %w", err)
+			}
 			if err := os.Rename(source, target); err != nil {
 				return fmt.Errorf("error moving source to target: %w", err)
 			}
 		} else {
-			if err := os.MkdirAll(target, os.ModeDir); err != nil {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
 				return fmt.Errorf("error creating target: %w", err)
 			}
 		}
@@ -1199,7 +1448,7 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 		}
 
 		if targetInfo.IsDir() {
-			err = windows.CreateJunctions([2]string{target, source})
+			err = scoop.Linker.LinkDir(target, source)
 		} else {
 			err = os.Link(target, source)
 		}
@@ -1207,30 +1456,55 @@ func (scoop *Scoop) install(iter *jsoniter.Iterator, appName string, arch Archit
 			return fmt.Errorf("error linking to persist target: %w", err)
 		}
 	}
-	if err := scoop.runScript(resolvedApp.PostInstall); err != nil {
-		return fmt.Errorf("error running post install script: %w", err)
-	}
 
 	return nil
 }
 
-func substituteVariables(value string, variables map[string]string) string {
-	// It seems like scoop does it this way as well. Instead of somehow checking
-	// whether there's a variable such as $directory, we simply replace $dir,
-	// not paying attention to potential damage done.
-	// FIXME However, this is error prone and should change in the future.
-	for key, val := range variables {
-		value = strings.ReplaceAll(value, key, val)
+// linkEnvironment sets the environment variables and creates the shortcuts of
+// the app. These are the only changes made outside of the scoop root.
+func (scoop *Scoop) linkEnvironment(resolvedApp *AppResolved, arch ArchitectureKey, versionDir string) error {
+	currentDir := filepath.Join(filepath.Dir(versionDir), "current")
+	variables := scoop.manifestVariables(resolvedApp.App, arch, currentDir, versionDir)
+
+	envVars := make([]EnvVar, 0, len(resolvedApp.EnvSet))
+	for _, envVar := range resolvedApp.EnvSet {
+		envVar.Value = variables.substitute(envVar.Value)
+		envVars = append(envVars, envVar)
+	}
+	if err := scoop.setAppEnv(resolvedApp.Name, envVars, scoop.AppPathEntries(resolvedApp.App)); err != nil {
+		return err
 	}
 
-	// FIXME Additionally, we need to substitute any $env:VARIABLE. The bullet
-	// proof way to do this, would be to simply invoke powershell, albeit a bit
-	// slow. This should happen before the in-code substitution.
+	if len(resolvedApp.Shortcuts) > 0 {
+		startmenuPath, err := scoop.ShortcutDir()
+		if errors.Is(err, errors.ErrUnsupported) {
+			fmt.Println("Skipping shortcuts, as they aren't supported on this platform")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	// This needs more investigation though, should probably read the docs on
-	// powershell env var substitution and see how easy it would be.
+		var winShortcuts []windows.Shortcut
+		for _, shortcut := range resolvedApp.Shortcuts {
+			var winShortcut windows.Shortcut
+			shortcutName := manifestPath(shortcut.ShortcutName)
+			winShortcut.Dir = filepath.Join(startmenuPath, filepath.Dir(shortcutName))
+			winShortcut.LinkTarget = filepath.Join(currentDir, manifestPath(shortcut.Name))
+			winShortcut.Alias = filepath.Base(shortcutName)
+			if shortcut.Icon != "" {
+				winShortcut.Icon = filepath.Join(currentDir, manifestPath(shortcut.Icon))
+			}
+			winShortcut.Args = variables.substitute(shortcut.Args)
+			winShortcuts = append(winShortcuts, winShortcut)
+		}
 
-	return value
+		if err := windows.CreateShortcuts(winShortcuts...); err != nil {
+			return fmt.Errorf("error creating shortcuts: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // extract will extract the given item. It doesn't matter which type it has, as
@@ -1249,7 +1523,7 @@ func (scoop *Scoop) extract(
 	fmt.Printf("Extracting '%s' ...\n", baseName)
 
 	fileToExtract := filepath.Join(cacheDir, CachePath(app.Name, app.Version, item.URL))
-	destinationDir := filepath.Join(appDir, item.ExtractTo)
+	destinationDir := filepath.Join(appDir, manifestPath(item.ExtractTo))
 
 	// Depending on metadata / filename, we decide how to extract the
 	// files that are to be installed. Note we don't care whether the
@@ -1356,7 +1630,7 @@ func (scoop *Scoop) extract(
 		// 7zip can't extract the ExtractDir files, it always creates the
 		// extract dir.
 		if item.ExtractDir != "" {
-			dirToMove := filepath.Join(destinationDir, item.ExtractDir)
+			dirToMove := filepath.Join(destinationDir, manifestPath(item.ExtractDir))
 			if err := windows.ExtractDir(dirToMove, destinationDir); err != nil {
 				return fmt.Errorf("error extracing dir: %w", err)
 			}
@@ -1397,7 +1671,7 @@ STD_ZIP:
 			}
 
 			// FIXME Prevent accidental mismatches
-			extractDir := filepath.ToSlash(item.ExtractDir)
+			extractDir := filepath.ToSlash(manifestPath(item.ExtractDir))
 			fName := filepath.ToSlash(f.Name)
 			if extractDir != "" && !strings.HasPrefix(fName, extractDir) {
 				continue
@@ -1407,7 +1681,7 @@ STD_ZIP:
 			// unless specified via extractTo
 			fName = strings.TrimLeft(strings.TrimPrefix(fName, extractDir), "/")
 
-			filePath := filepath.Join(appDir, item.ExtractTo, fName)
+			filePath := filepath.Join(destinationDir, fName)
 			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
 				return fmt.Errorf("error creating dir: %w", err)
 			}
@@ -1431,7 +1705,7 @@ STD_ZIP:
 		}
 	} else {
 		targetFile, err := os.OpenFile(
-			filepath.Join(appDir, item.ExtractTo, baseName),
+			filepath.Join(destinationDir, baseName),
 			os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
 			0o600,
 		)
@@ -1506,9 +1780,10 @@ type AppResolved struct {
 
 	// Installer deprecates msi; InnoSetup bool should be same for each
 	// architecture. The docs don't mention it.
-	Installer   *Installer `json:"installer"`
-	PreInstall  []string   `json:"pre_install"`
-	PostInstall []string   `json:"post_install"`
+	Installer   *Installer   `json:"installer"`
+	Uninstaller *Uninstaller `json:"uninstaller"`
+	PreInstall  []string     `json:"pre_install"`
+	PostInstall []string     `json:"post_install"`
 }
 
 // ForArch will create a merged version that includes all the relevant fields at
@@ -1525,6 +1800,7 @@ func (a *App) ForArch(arch ArchitectureKey) *AppResolved {
 	resolved.PreInstall = a.PreInstall
 	resolved.PostInstall = a.PostInstall
 	resolved.Installer = a.Installer
+	resolved.Uninstaller = a.Uninstaller
 
 	if a.Architecture == nil {
 		return resolved
@@ -1559,6 +1835,12 @@ func (a *App) ForArch(arch ArchitectureKey) *AppResolved {
 		if len(archValue.PostInstall) > len(resolved.PostInstall) {
 			resolved.PostInstall = archValue.PostInstall
 		}
+		if archValue.Installer != nil {
+			resolved.Installer = archValue.Installer
+		}
+		if archValue.Uninstaller != nil {
+			resolved.Uninstaller = archValue.Uninstaller
+		}
 	}
 
 	// architecture does not support extract_to, so we merge it with the root
@@ -1582,10 +1864,12 @@ func (a *App) ForArch(arch ArchitectureKey) *AppResolved {
 	// If we have neither an installer file, nor a script, we reference the last
 	// items downloaded, as per scoop documentation.
 	// FIXME Find out if this is really necessary, this is jank.
-	if a.Installer != nil && a.Installer.File == "" &&
-		len(a.Installer.Script) == 0 && len(a.Downloadables) > 0 {
-		lastURL := resolved.Downloadables[len(a.Downloadables)-1].URL
-		a.Installer.File = filepath.Base(lastURL)
+	if resolved.Installer != nil && resolved.Installer.File == "" &&
+		len(resolved.Installer.Script) == 0 && len(resolved.Downloadables) > 0 {
+		// Copy, to prevent changing the original app.
+		installer := *resolved.Installer
+		installer.File = filepath.Base(resolved.Downloadables[len(resolved.Downloadables)-1].URL)
+		resolved.Installer = &installer
 	}
 
 	return resolved
@@ -1717,6 +2001,30 @@ func (scoop *Scoop) CacheDir() string {
 
 type Scoop struct {
 	scoopRoot string
+
+	// ProcessRunner is used to invoke installer and uninstaller executables.
+	ProcessRunner ProcessRunner
+	// ScriptRunner is used to run the PowerShell scripts of manifests, such
+	// as pre_install and installer.script.
+	ScriptRunner ScriptRunner
+	// Linker creates the `current` dir of apps and the links into the
+	// persist dir.
+	Linker Linker
+	// EnvStore holds the persistent user environment, which apps modify on
+	// installation and uninstallation.
+	EnvStore EnvStore
+	// Isolated installations never modify the persistent user environment
+	// and don't create start menu shortcuts. This is meant for temporary
+	// environments, such as the ones created by `spoon shell`.
+	Isolated bool
+	// AuditPolicy is checked before installing an app. If the manifest
+	// violates the policy, the installation fails with an
+	// [*AuditBlockedError]. If nil, everything is allowed.
+	AuditPolicy *AuditPolicy
+	// SharedAppDir is the app dir of another scoop, usually the default one.
+	// Versions already installed there are hardlinked instead of being
+	// downloaded and extracted again.
+	SharedAppDir string
 }
 
 func (scoop *Scoop) AppDir() string {
@@ -1724,7 +2032,7 @@ func (scoop *Scoop) AppDir() string {
 }
 
 func (scoop Scoop) ShortcutDir() (string, error) {
-	startmenuPath, err := windows.GetFolderPath("StartMenu")
+	startmenuPath, err := windows.StartMenuDir()
 	if err != nil {
 		return "", fmt.Errorf("error determining start menu path: %w", err)
 	}
@@ -1741,6 +2049,13 @@ func NewScoop() (*Scoop, error) {
 
 func NewCustomScoop(scoopRoot string) *Scoop {
 	return &Scoop{
-		scoopRoot: scoopRoot,
+		scoopRoot:     scoopRoot,
+		ProcessRunner: ExecProcessRunner{},
+		ScriptRunner: &PowerShellRunner{
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		},
+		Linker:   windows.DefaultLinker{},
+		EnvStore: windows.UserEnv{},
 	}
 }
@@ -1,7 +1,10 @@
 package scoop_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Bios-Marcel/spoon/pkg/scoop"
 	"github.com/stretchr/testify/require"
@@ -152,3 +155,72 @@ func Test_ParseArchitecture_Items(t *testing.T) {
 	require.NotEmpty(t, arm64.Downloadables[0].Hash)
 	require.Empty(t, arm64.Downloadables[0].ExtractDir)
 }
+
+func Test_InstalledApps(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"),
+		[]byte(`{"version": "1.0.0"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+		[]byte(`{"bucket": "main", "architecture": "32bit", "hold": true}`), 0o600))
+	installDate := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	require.NoError(t, os.Chtimes(filepath.Join(currentDir, "manifest.json"), installDate, installDate))
+
+	apps, err := customScoop.InstalledApps()
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+
+	app := apps[0]
+	require.Equal(t, "app", app.Name)
+	require.Equal(t, "main", app.Bucket.Name())
+	require.Equal(t, scoop.ArchitectureKey32Bit, app.Architecture)
+	require.True(t, app.Hold)
+	require.True(t, installDate.Equal(app.InstallDate))
+
+	require.NoError(t, app.LoadDetails(scoop.DetailFieldVersion))
+	require.Equal(t, "1.0.0", app.Version)
+
+	size, err := customScoop.AppSize("app")
+	require.NoError(t, err)
+	require.EqualValues(t, len(`{"version": "1.0.0"}`)+len(`{"bucket": "main", "architecture": "32bit", "hold": true}`), size)
+
+	// Holding rewrites the install.json, but isn't an installation.
+	require.NoError(t, customScoop.Hold("app", scoop.HoldOptions{}))
+	app, err = customScoop.FindInstalledApp("app")
+	require.NoError(t, err)
+	require.True(t, installDate.Equal(app.InstallDate))
+}
+
+func Test_ParseHomepageLicenseAndDepends(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	bucketDir := filepath.Join(customScoop.BucketDir(), "custom", "bucket")
+	require.NoError(t, os.MkdirAll(bucketDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(bucketDir, "a.json"), []byte(`{
+		"homepage": "https://example.com",
+		"license": {"identifier": "MIT", "url": "https://example.com/LICENSE"},
+		"depends": ["b", "main/c"]
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(bucketDir, "b.json"), []byte(`{
+		"license": "Freeware"
+	}`), 0o600))
+
+	a, err := customScoop.FindAvailableApp("custom/a")
+	require.NoError(t, err)
+	require.NoError(t, a.LoadDetails(scoop.DetailFieldsAll...))
+	require.Equal(t, "https://example.com", a.Homepage)
+	require.Equal(t, "MIT", a.License)
+	require.Equal(t, []scoop.Dependency{
+		{Bucket: "custom", Name: "b"},
+		{Bucket: "main", Name: "c"},
+	}, a.Depends)
+
+	b, err := customScoop.FindAvailableApp("custom/b")
+	require.NoError(t, err)
+	require.NoError(t, b.LoadDetails(scoop.DetailFieldLicense))
+	require.Equal(t, "Freeware", b.License)
+}
@@ -0,0 +1,254 @@
+package scoop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+)
+
+// ScriptHook identifies the manifest field a script originates from.
+type ScriptHook string
+
+const (
+	ScriptHookPreInstall    ScriptHook = "pre_install"
+	ScriptHookInstaller     ScriptHook = "installer.script"
+	ScriptHookPostInstall   ScriptHook = "post_install"
+	ScriptHookPreUninstall  ScriptHook = "pre_uninstall"
+	ScriptHookUninstaller   ScriptHook = "uninstaller.script"
+	ScriptHookPostUninstall ScriptHook = "post_uninstall"
+)
+
+// Script is a PowerShell script defined in a manifest.
+type Script struct {
+	Hook ScriptHook
+	// App is the name of the app the script belongs to.
+	App string
+	// Lines are the lines as defined in the manifest.
+	Lines []string
+	// Variables are defined before the script runs, so that the script can
+	// use them natively, just like in scoop. Names don't include the `$`.
+	Variables map[string]string
+}
+
+// Source returns the lines to actually run, which is the script prefixed with
+// the variable definitions.
+func (script *Script) Source() []string {
+	return append(manifestVariables(script.Variables).powershellPrelude(), script.Lines...)
+}
+
+// ScriptRunner runs manifest scripts. This allows replacing the actual
+// execution, for example for dry runs or tests.
+type ScriptRunner interface {
+	// Run blocks until the script has finished. An error is returned if the
+	// script couldn't be run or failed.
+	Run(script *Script) error
+}
+
+// PowerShellRunner is the default [ScriptRunner], which runs scripts using
+// pwsh or powershell.
+type PowerShellRunner struct {
+	// Executable is the PowerShell to use. If empty, the shell spoon has been
+	// started from is used if it is pwsh or powershell. Otherwise, we fall
+	// back to powershell.
+	Executable string
+	// NoProfile prevents loading the profile of the user, so that scripts
+	// aren't affected by customisations.
+	NoProfile bool
+	// Stdout and Stderr receive the output of the scripts. If nil, the output
+	// is discarded.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (runner *PowerShellRunner) Run(script *Script) error {
+	executable := runner.Executable
+	if executable == "" {
+		executable = defaultPowerShell()
+	}
+
+	// Passing the script via a file instead of stdin allows multi-line
+	// statements and causes failures to be reported via the exit code.
+	file, err := os.CreateTemp("", "spoon-*.ps1")
+	if err != nil {
+		return fmt.Errorf("error creating script file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	// Windows PowerShell reads files without a BOM using the ANSI codepage,
+	// breaking non-ASCII characters, for example in paths.
+	_, err = file.WriteString(utf8BOM + strings.Join(script.Source(), "\r\n"))
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("error writing script file: %w", err)
+	}
+
+	args := []string{"-NoLogo", "-NonInteractive"}
+	if runner.NoProfile {
+		args = append(args, "-NoProfile")
+	}
+	args = append(args, "-ExecutionPolicy", "Bypass", "-File", file.Name())
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = runner.Stdout
+	cmd.Stderr = runner.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &ExitCodeError{Executable: executable, Code: exitErr.ExitCode()}
+		}
+		return fmt.Errorf("error running %s: %w", executable, err)
+	}
+	return nil
+}
+
+const utf8BOM = "\ufeff"
+
+func defaultPowerShell() string {
+	shell, err := windows.GetShellExecutable()
+	if err != nil {
+		return "powershell.exe"
+	}
+
+	switch shell = strings.ToLower(shell); shell {
+	case "pwsh.exe", "powershell.exe":
+		return shell
+	default:
+		return "powershell.exe"
+	}
+}
+
+// RecordingScriptRunner doesn't run any scripts, but records them. This can
+// be used for dry runs, to find out which scripts an installation would run.
+type RecordingScriptRunner struct {
+	mutex   sync.Mutex
+	scripts []*Script
+}
+
+func (runner *RecordingScriptRunner) Run(script *Script) error {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	recorded := *script
+	recorded.Lines = slices.Clone(script.Lines)
+	recorded.Variables = maps.Clone(script.Variables)
+	runner.scripts = append(runner.scripts, &recorded)
+	return nil
+}
+
+// Scripts returns all recorded scripts in the order they would have run.
+func (runner *RecordingScriptRunner) Scripts() []*Script {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	return slices.Clone(runner.scripts)
+}
+
+// ScriptEvent is sent by the [StreamingScriptRunner]. It is either a
+// [*ScriptStarted], [*ScriptOutput] or [*ScriptFinished].
+type ScriptEvent interface {
+	isScriptEvent()
+}
+
+// ScriptStarted is sent by the [StreamingScriptRunner] before a script runs.
+type ScriptStarted struct {
+	Script *Script
+}
+
+func (*ScriptStarted) isScriptEvent() {}
+
+// ScriptOutput is a single line written by a script.
+type ScriptOutput struct {
+	Script *Script
+	Line   string
+	// Stderr indicates whether the line has been written to stderr instead
+	// of stdout.
+	Stderr bool
+}
+
+func (*ScriptOutput) isScriptEvent() {}
+
+// ScriptFinished is sent by the [StreamingScriptRunner] after a script ran.
+// Err is nil if the script succeeded.
+type ScriptFinished struct {
+	Script *Script
+	Err    error
+}
+
+func (*ScriptFinished) isScriptEvent() {}
+
+// StreamingScriptRunner runs scripts using PowerShell and passes their output
+// line by line to the event sink. The sink receives a [*ScriptStarted], any
+// amount of [*ScriptOutput] and a [*ScriptFinished] per script. Events are
+// sent from a single goroutine at a time.
+type StreamingScriptRunner struct {
+	// PowerShell configures the executable and profile. The output writers
+	// are ignored.
+	PowerShell PowerShellRunner
+	Sink       func(event ScriptEvent)
+}
+
+func (runner *StreamingScriptRunner) Run(script *Script) error {
+	var mutex sync.Mutex
+	send := func(event ScriptEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		runner.Sink(event)
+	}
+
+	send(&ScriptStarted{Script: script})
+
+	stdout := &lineWriter{onLine: func(line string) {
+		send(&ScriptOutput{Script: script, Line: line})
+	}}
+	stderr := &lineWriter{onLine: func(line string) {
+		send(&ScriptOutput{Script: script, Line: line, Stderr: true})
+	}}
+
+	powerShell := runner.PowerShell
+	powerShell.Stdout = stdout
+	powerShell.Stderr = stderr
+	err := powerShell.Run(script)
+	stdout.flush()
+	stderr.flush()
+
+	send(&ScriptFinished{Script: script, Err: err})
+	return err
+}
+
+// lineWriter calls onLine for every complete line written. Carriage returns
+// at the end of lines are dropped.
+type lineWriter struct {
+	buffer bytes.Buffer
+	onLine func(line string)
+}
+
+func (writer *lineWriter) Write(data []byte) (int, error) {
+	writer.buffer.Write(data)
+	for {
+		line, err := writer.buffer.ReadString('\n')
+		if err != nil {
+			// Incomplete line, wait for the rest.
+			writer.buffer.Reset()
+			writer.buffer.WriteString(line)
+			return len(data), nil
+		}
+		writer.onLine(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// flush passes on the last line, if it didn't end with a line break.
+func (writer *lineWriter) flush() {
+	if writer.buffer.Len() > 0 {
+		writer.onLine(strings.TrimRight(writer.buffer.String(), "\r"))
+		writer.buffer.Reset()
+	}
+}
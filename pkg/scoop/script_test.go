@@ -0,0 +1,130 @@
+package scoop_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePowerShell creates an executable printing the script file passed as the
+// last argument and exiting with the given code.
+func fakePowerShell(t *testing.T, exitCode string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executable is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "pwsh")
+	script := "#!/bin/sh\n" +
+		"for last; do :; done\n" +
+		"cat \"$last\"\n" +
+		"echo failure >&2\n" +
+		"exit " + exitCode + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700))
+	return path
+}
+
+func Test_StreamingScriptRunner(t *testing.T) {
+	t.Parallel()
+
+	script := &scoop.Script{
+		Hook:      scoop.ScriptHookPostInstall,
+		App:       "app",
+		Lines:     []string{"Write-Host 'a'", "Write-Host 'b'"},
+		Variables: map[string]string{"dir": `C:\app`},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		var events []scoop.ScriptEvent
+		runner := &scoop.StreamingScriptRunner{
+			PowerShell: scoop.PowerShellRunner{Executable: fakePowerShell(t, "0")},
+			Sink:       func(event scoop.ScriptEvent) { events = append(events, event) },
+		}
+		require.NoError(t, runner.Run(script))
+
+		require.Len(t, events, 6)
+		require.Equal(t, &scoop.ScriptStarted{Script: script}, events[0])
+		require.Equal(t, &scoop.ScriptFinished{Script: script}, events[5])
+
+		// Stdout and stderr are written concurrently, so the order between
+		// them isn't defined.
+		// The fake prints the script file as is, including its BOM.
+		require.ElementsMatch(t, []scoop.ScriptEvent{
+			&scoop.ScriptOutput{Script: script, Line: "\ufeff$dir = 'C:\\app'"},
+			&scoop.ScriptOutput{Script: script, Line: "Write-Host 'a'"},
+			&scoop.ScriptOutput{Script: script, Line: "Write-Host 'b'"},
+			&scoop.ScriptOutput{Script: script, Line: "failure", Stderr: true},
+		}, events[1:5])
+	})
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+
+		var finished *scoop.ScriptFinished
+		runner := &scoop.StreamingScriptRunner{
+			PowerShell: scoop.PowerShellRunner{Executable: fakePowerShell(t, "3")},
+			Sink: func(event scoop.ScriptEvent) {
+				if event, ok := event.(*scoop.ScriptFinished); ok {
+					finished = event
+				}
+			},
+		}
+
+		err := runner.Run(script)
+		var exitErr *scoop.ExitCodeError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 3, exitErr.Code)
+		require.NotNil(t, finished)
+		require.Equal(t, err, finished.Err)
+	})
+}
+
+func Test_PowerShellRunner(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	runner := &scoop.PowerShellRunner{
+		Executable: fakePowerShell(t, "0"),
+		Stdout:     &stdout,
+	}
+	require.NoError(t, runner.Run(&scoop.Script{
+		Hook:  scoop.ScriptHookPostInstall,
+		App:   "app",
+		Lines: []string{"Write-Host 'ä'", "Write-Host 'b'"},
+	}))
+
+	// Windows PowerShell requires a BOM to read the file as UTF-8.
+	require.Equal(t, "\ufeffWrite-Host 'ä'\r\nWrite-Host 'b'", stdout.String())
+}
+
+func Test_RecordingScriptRunner(t *testing.T) {
+	t.Parallel()
+
+	runner := &scoop.RecordingScriptRunner{}
+	script := &scoop.Script{
+		Hook:      scoop.ScriptHookPreInstall,
+		App:       "app",
+		Lines:     []string{"Write-Host $dir"},
+		Variables: map[string]string{"dir": `C:\app`},
+	}
+	require.NoError(t, runner.Run(script))
+
+	// Later changes mustn't affect the recording.
+	script.Lines[0] = "changed"
+	script.Variables["dir"] = "changed"
+
+	require.Equal(t, []*scoop.Script{{
+		Hook:      scoop.ScriptHookPreInstall,
+		App:       "app",
+		Lines:     []string{"Write-Host $dir"},
+		Variables: map[string]string{"dir": `C:\app`},
+	}}, runner.Scripts())
+	require.Equal(t, []string{`$dir = 'C:\app'`, "Write-Host $dir"}, runner.Scripts()[0].Source())
+}
@@ -0,0 +1,138 @@
+package scoop
+
+import (
+	stdJson "encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+)
+
+// reuseSharedVersion hardlinks the files of an existing installation of the
+// app in [Scoop.SharedAppDir] into versionDir, instead of downloading and
+// extracting them again. Hardlinks are basically free, but share their
+// content and attributes, so changes to existing files affect both
+// installations. Therefore no manifest scripts or installers are run for
+// reused versions, neither on installation nor on uninstallation, and the
+// installation is marked as shared in its install.json. Note that deleting a
+// read-only file on Windows requires clearing its read-only attribute, which
+// applies to the shared installation as well.
+//
+// Only the current version of the shared installation is reused. Persisted
+// files and links aren't reused, as they belong to the other installation.
+// The manifest.json and install.json are written by the installation itself.
+//
+// False is returned if the version isn't installed for the given
+// architecture or if the files can't be hardlinked, for example because they
+// are on a different volume. In that case, versionDir doesn't exist.
+func (scoop *Scoop) reuseSharedVersion(
+	resolvedApp *AppResolved,
+	arch ArchitectureKey,
+	versionDir string,
+) (bool, error) {
+	if scoop.SharedAppDir == "" {
+		return false, nil
+	}
+
+	sharedVersionDir := filepath.Join(scoop.SharedAppDir, resolvedApp.Name, resolvedApp.Version)
+	if filepath.Clean(sharedVersionDir) == filepath.Clean(versionDir) {
+		return false, nil
+	}
+
+	// Installations that failed half way are never reused. The install.json
+	// is written before the version is linked as `current`, so it is not
+	// sufficient on its own.
+	sharedVersionInfo, err := os.Stat(sharedVersionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking shared installation: %w", err)
+	}
+	currentInfo, err := os.Stat(filepath.Join(scoop.SharedAppDir, resolvedApp.Name, "current"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking shared installation: %w", err)
+	}
+	if !os.SameFile(sharedVersionInfo, currentInfo) {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(sharedVersionDir, "install.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading shared install.json: %w", err)
+	}
+	var installInfo struct {
+		Architecture ArchitectureKey `json:"architecture"`
+	}
+	if err := stdJson.Unmarshal(data, &installInfo); err != nil {
+		return false, fmt.Errorf("error parsing shared install.json: %w", err)
+	}
+	if installInfo.Architecture != arch {
+		return false, nil
+	}
+
+	skip := map[string]bool{
+		"manifest.json": true,
+		"install.json":  true,
+	}
+	for _, entry := range resolvedApp.Persist {
+		skip[strings.ToLower(filepath.Clean(manifestPath(entry.Dir)))] = true
+	}
+
+	err = filepath.WalkDir(sharedVersionDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(sharedVersionDir, path)
+		if err != nil {
+			return err
+		}
+		if skip[strings.ToLower(relPath)] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Junctions aren't reported as dirs, so they are never entered.
+		isLink, err := windows.IsLink(path)
+		if err != nil {
+			return err
+		}
+		if isLink {
+			return nil
+		}
+
+		target := filepath.Join(versionDir, relPath)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		return os.Link(path, target)
+	})
+	if err != nil {
+		if removeErr := windows.ForceRemoveAll(versionDir); removeErr != nil {
+			return false, fmt.Errorf("error cleaning up version dir: %w", removeErr)
+		}
+
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) {
+			fmt.Printf("Can't hardlink shared installation, extracting instead: %s\n", err)
+			return false, nil
+		}
+		return false, fmt.Errorf("error reusing shared installation: %w", err)
+	}
+
+	fmt.Printf("Reusing installation from '%s'\n", sharedVersionDir)
+	return true, nil
+}
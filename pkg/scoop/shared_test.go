@@ -0,0 +1,83 @@
+package scoop_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReuseSharedVersion(t *testing.T) {
+	t.Parallel()
+
+	sharedScoop := scoop.NewCustomScoop(t.TempDir())
+	sharedVersionDir := filepath.Join(sharedScoop.AppDir(), "app", "1.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(sharedVersionDir, "bin"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(sharedVersionDir, "data"), os.ModePerm))
+	for path, content := range map[string]string{
+		"app.exe":          "binary",
+		"bin/tool.exe":     "tool",
+		"data/state":       "persisted",
+		"manifest.json":    "{}",
+		"install.json":     `{"architecture": "64bit"}`,
+		"config.ini":       "persisted",
+		"unrelated.config": "content",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(sharedVersionDir, path), []byte(content), 0o600))
+	}
+
+	resolvedApp := &scoop.AppResolved{App: &scoop.App{
+		Name:    "app",
+		Version: "1.0",
+		Persist: []scoop.PersistDir{{Dir: "data"}, {Dir: "config.ini"}},
+	}}
+
+	shellScoop := scoop.NewCustomScoop(t.TempDir())
+	shellScoop.SharedAppDir = sharedScoop.AppDir()
+	versionDir := filepath.Join(shellScoop.AppDir(), "app", "1.0")
+
+	t.Run("not current", func(t *testing.T) {
+		reused, err := scoop.ReuseSharedVersion(shellScoop, resolvedApp, scoop.ArchitectureKey64Bit, versionDir)
+		require.NoError(t, err)
+		require.False(t, reused)
+		require.NoDirExists(t, versionDir)
+	})
+
+	// Installations are only complete once they've been linked.
+	require.NoError(t, windows.DefaultLinker{}.LinkDir(
+		sharedVersionDir, filepath.Join(sharedScoop.AppDir(), "app", "current")))
+
+	t.Run("architecture mismatch", func(t *testing.T) {
+		reused, err := scoop.ReuseSharedVersion(shellScoop, resolvedApp, scoop.ArchitectureKey32Bit, versionDir)
+		require.NoError(t, err)
+		require.False(t, reused)
+		require.NoDirExists(t, versionDir)
+	})
+	t.Run("version not installed", func(t *testing.T) {
+		otherVersion := &scoop.AppResolved{App: &scoop.App{Name: "app", Version: "2.0"}}
+		reused, err := scoop.ReuseSharedVersion(shellScoop, otherVersion, scoop.ArchitectureKey64Bit, versionDir)
+		require.NoError(t, err)
+		require.False(t, reused)
+		require.NoDirExists(t, versionDir)
+	})
+	t.Run("reused", func(t *testing.T) {
+		reused, err := scoop.ReuseSharedVersion(shellScoop, resolvedApp, scoop.ArchitectureKey64Bit, versionDir)
+		require.NoError(t, err)
+		require.True(t, reused)
+
+		for _, path := range []string{"app.exe", "bin/tool.exe", "unrelated.config"} {
+			sharedInfo, err := os.Stat(filepath.Join(sharedVersionDir, path))
+			require.NoError(t, err)
+			info, err := os.Stat(filepath.Join(versionDir, path))
+			require.NoError(t, err)
+			require.True(t, os.SameFile(sharedInfo, info), path)
+		}
+		for _, path := range []string{"data", "config.ini", "manifest.json", "install.json"} {
+			require.NoFileExists(t, filepath.Join(versionDir, path))
+			require.NoDirExists(t, filepath.Join(versionDir, path))
+		}
+	})
+}
@@ -7,15 +7,16 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	_ "embed"
 )
 
-// //go:embed shim_jar_to_cmd.template
+//go:embed shim_jar_to_cmd.template
 var jarToCmdTemplate string
 
-// //go:embed shim_jar_to_bash.template
+//go:embed shim_jar_to_bash.template
 var jarToBashTemplate string
 
 //go:embed shim_cmd_to_cmd.template
@@ -24,49 +25,157 @@ var cmdToCmdTemplate string
 //go:embed shim_cmd_to_bash.template
 var cmdToBashTemplate string
 
+//go:embed shim_ps1_to_ps1.template
+var ps1ToPs1Template string
+
+//go:embed shim_ps1_to_cmd.template
+var ps1ToCmdTemplate string
+
+//go:embed shim_ps1_to_bash.template
+var ps1ToBashTemplate string
+
+//go:embed shim_py_to_cmd.template
+var pyToCmdTemplate string
+
+//go:embed shim_py_to_bash.template
+var pyToBashTemplate string
+
 //go:embed shim.exe
 var shimExecutable []byte
 
-// FIXME Should this be a public helper function on Bin? If so, we should
-// probably split bin and shortcut. At this point, they don't seem to be to
-// compatible anymore.
-func shimName(bin Bin) string {
+// ShimName is the name of the shim created for the bin, without extension.
+func (bin Bin) ShimName() string {
 	shimName := bin.Alias
 	if shimName == "" {
-		shimName = filepath.Base(bin.Name)
+		shimName = filepath.Base(manifestPath(bin.Name))
 		shimName = strings.TrimSuffix(shimName, filepath.Ext(shimName))
 	}
 	return shimName
 }
 
-func (scoop *Scoop) RemoveShims(bins ...Bin) error {
-	return filepath.WalkDir(scoop.ShimDir(), func(path string, d fs.DirEntry, err error) error {
+// RemoveShims removes the shims of the given app. Shims that have been
+// overwritten by another app or the user are kept. If another installed app
+// provides the same shim, the shim is pointed to that app instead.
+func (scoop *Scoop) RemoveShims(appName string, bins ...Bin) error {
+	return scoop.removeShims(appName, nil, bins)
+}
+
+// removeShims is [Scoop.RemoveShims], but keeps all shims also provided by
+// the bins in keep. On updates, these are the shims of the new version, which
+// are recreated right away, so handing them to another app would only cause
+// them to be taken back.
+func (scoop *Scoop) removeShims(appName string, keep, bins []Bin) error {
+	registry, err := scoop.LoadShimRegistry()
+	if err != nil {
+		return err
+	}
+
+	for _, bin := range bins {
+		shimName := bin.ShimName()
+		if slices.ContainsFunc(keep, func(keptBin Bin) bool {
+			return strings.EqualFold(keptBin.ShimName(), shimName)
+		}) {
+			continue
+		}
+
+		owner, err := scoop.shimOwner(registry, shimName)
 		if err != nil {
 			return err
 		}
+		if !strings.EqualFold(owner, appName) {
+			continue
+		}
 
-		if d.IsDir() {
-			return nil
+		if err := scoop.deleteShimFiles(shimName); err != nil {
+			return err
 		}
+		registry.Set(shimName, nil)
 
-		for _, bin := range bins {
-			// This will catch all file types, including the shims.
-			shimName := shimName(bin)
-			binWithoutExt := strings.TrimSuffix(shimName, filepath.Ext(shimName))
-			nameWithoutExt := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
-			if !strings.EqualFold(nameWithoutExt, binWithoutExt) {
+		providers, err := scoop.ShimProviders(shimName)
+		if err != nil {
+			return err
+		}
+		for _, provider := range providers {
+			if strings.EqualFold(provider.App.Name, appName) {
 				continue
 			}
 
-			if err := os.Remove(path); err != nil {
-				return fmt.Errorf("error deleting shim '%s': %w", path, err)
+			fmt.Printf("Pointing shim '%s' to '%s'\n", shimName, provider.App.Name)
+			if err := scoop.createAppShim(provider.App.App, provider.App.Architecture, provider.Bin); err != nil {
+				return err
 			}
+			registry.Set(shimName, &ShimRegistration{Owner: provider.App.Name})
+			break
+		}
+	}
+
+	return scoop.SaveShimRegistry(registry)
+}
+
+// shimOwner returns the app owning the given shim. Shims created before
+// ownership was recorded are attributed to the app containing their target.
+// User defined shims and shims not pointing into any app have no owner.
+func (scoop *Scoop) shimOwner(registry ShimRegistry, name string) (string, error) {
+	if registration := registry.Get(name); registration != nil {
+		return registration.Owner, nil
+	}
+
+	shim, err := scoop.FindShim(name)
+	if err != nil || shim == nil {
+		return "", err
+	}
+	owner, _, err := scoop.FindOwningApp(shim.Target)
+	return owner, err
+}
+
+// deleteShimFiles deletes all files belonging to the shim with the given name,
+// no matter who created them.
+func (scoop *Scoop) deleteShimFiles(name string) error {
+	return filepath.WalkDir(scoop.ShimDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
 		}
 
+		// This will catch all file types, including the shims.
+		nameWithoutExt := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		if !strings.EqualFold(nameWithoutExt, name) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("error deleting shim '%s': %w", path, err)
+		}
 		return nil
 	})
 }
 
+// createAppShim creates the shim for a bin of the app, pointing into the
+// `current` dir of the app. Manifest variables in the args are substituted.
+// The version of the app needs to be loaded.
+func (scoop *Scoop) createAppShim(app *App, arch ArchitectureKey, bin Bin) error {
+	appDir := filepath.Join(scoop.AppDir(), app.Name)
+	currentDir := filepath.Join(appDir, "current")
+	variables := scoop.manifestVariables(app, arch, currentDir, filepath.Join(appDir, app.Version))
+
+	// The args are shared with the app, so we mustn't substitute in place.
+	bin.Args = slices.Clone(bin.Args)
+	for index, arg := range bin.Args {
+		bin.Args[index] = variables.substitute(arg)
+	}
+
+	if err := scoop.CreateShim(filepath.Join(currentDir, manifestPath(bin.Name)), bin); err != nil {
+		return fmt.Errorf("error creating shim: %w", err)
+	}
+	return nil
+}
+
 func (scoop *Scoop) CreateShim(path string, bin Bin) error {
 	/*
 		We got the following possible constructs:
@@ -99,23 +208,23 @@ func (scoop *Scoop) CreateShim(path string, bin Bin) error {
 		executable.
 	*/
 
-	shimName := shimName(bin)
+	shimName := bin.ShimName()
+	for _, arg := range bin.Args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return fmt.Errorf("shim arguments must not contain line breaks: %q", arg)
+		}
+	}
+	if err := os.MkdirAll(scoop.ShimDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating shim dir: %w", err)
+	}
 
 	switch filepath.Ext(bin.Name) {
 	case ".exe", ".com":
-		// FIXME Do we need to escape anything here?
-		argsJoined := strings.Join(bin.Args, " ")
-
 		// The .shim and .exe files needs to be writable, as scoop fails to
 		// uninstall otherwise.
 		var shimConfig bytes.Buffer
-		shimConfig.WriteString(`path = "`)
-		shimConfig.WriteString(path)
-		shimConfig.WriteString("\"\n")
-		if argsJoined != "" {
-			shimConfig.WriteString(`args = `)
-			shimConfig.WriteString(argsJoined)
-			shimConfig.WriteRune('\n')
+		if err := (ShimConfig{Path: path, Args: strings.Join(bin.Args, " ")}).Write(&shimConfig); err != nil {
+			return err
 		}
 		if err := os.WriteFile(filepath.Join(scoop.ShimDir(), shimName+".shim"),
 			shimConfig.Bytes(), 0o600); err != nil {
@@ -128,43 +237,26 @@ func (scoop *Scoop) CreateShim(path string, bin Bin) error {
 			return fmt.Errorf("error creating shim executable: %w", err)
 		}
 	case ".cmd", ".bat":
-		// FIXME Do we need to escape anything here?
-		argsJoined := strings.Join(bin.Args, " ")
-
-		if err := os.WriteFile(
-			filepath.Join(scoop.ShimDir(), shimName+".cmd"),
-			[]byte(fmt.Sprintf(cmdToCmdTemplate, path, path, argsJoined)),
-			0o700,
-		); err != nil {
-			return fmt.Errorf("error creating cmdShim: %w", err)
-		}
-		if err := os.WriteFile(
-			filepath.Join(scoop.ShimDir(), shimName),
-			[]byte(fmt.Sprintf(cmdToBashTemplate, path, path, argsJoined)),
-			0o700,
-		); err != nil {
-			return fmt.Errorf("error creating cmdShim: %w", err)
-		}
+		return scoop.writeShimTemplates(shimName, path, bin.Args,
+			shimTemplate{".cmd", cmdToCmdTemplate, cmdDialect},
+			shimTemplate{"", cmdToBashTemplate, shDialect},
+		)
 	case ".ps1":
+		return scoop.writeShimTemplates(shimName, path, bin.Args,
+			shimTemplate{".ps1", ps1ToPs1Template, powerShellDialect},
+			shimTemplate{".cmd", ps1ToCmdTemplate, cmdDialect},
+			shimTemplate{"", ps1ToBashTemplate, shDialect},
+		)
 	case ".jar":
-		// FIXME Do we need to escape anything here?
-		argsJoined := strings.Join(bin.Args, " ")
-
-		if err := os.WriteFile(
-			filepath.Join(scoop.ShimDir(), shimName+".cmd"),
-			[]byte(fmt.Sprintf(jarToCmdTemplate, path, path, argsJoined)),
-			0o700,
-		); err != nil {
-			return fmt.Errorf("error creating cmdShim: %w", err)
-		}
-		if err := os.WriteFile(
-			filepath.Join(scoop.ShimDir(), shimName),
-			[]byte(fmt.Sprintf(jarToBashTemplate, path, path, argsJoined)),
-			0o700,
-		); err != nil {
-			return fmt.Errorf("error creating cmdShim: %w", err)
-		}
+		return scoop.writeShimTemplates(shimName, path, bin.Args,
+			shimTemplate{".cmd", jarToCmdTemplate, cmdDialect},
+			shimTemplate{"", jarToBashTemplate, shDialect},
+		)
 	case ".py":
+		return scoop.writeShimTemplates(shimName, path, bin.Args,
+			shimTemplate{".cmd", pyToCmdTemplate, cmdDialect},
+			shimTemplate{"", pyToBashTemplate, shDialect},
+		)
 	default:
 		// FIXME Do we want to implement this case?
 		return errors.New("this package contains a currently unsupported shim-type, please contact the maintainer")
@@ -176,3 +268,253 @@ func (scoop *Scoop) CreateShim(path string, bin Bin) error {
 func (scoop *Scoop) ShimDir() string {
 	return filepath.Join(scoop.scoopRoot, "shims")
 }
+
+// Shim is a shim found in the shim dir. Note that one shim can consist of
+// multiple files, for example `.shim` and `.exe`, or `.cmd` and a bash script.
+type Shim struct {
+	// Name is the name of the shim without any extension.
+	Name string
+	// Path is the file invoked by the user, for example the `.exe`.
+	Path string
+	// ConfigPath is the file defining the target. This might be the same
+	// as [Shim.Path].
+	ConfigPath string
+	// Target is the file that is invoked by the shim.
+	Target string
+	// Args are additional arguments that the shim passes to the target.
+	Args string
+}
+
+// shimExtensions defines in which order shims are looked up. The first item is
+// the file that the user invokes, the second is the one containing the target.
+var shimExtensions = [][2]string{
+	{".exe", ".shim"},
+	{".cmd", ".cmd"},
+	{".ps1", ".ps1"},
+	{"", ""},
+}
+
+// FindShim looks up the shim with the given name. An executable extension on
+// the name is ignored, while other dotted suffixes, such as in `python3.12`,
+// are part of the name. If no shim is found, nil is returned.
+func (scoop *Scoop) FindShim(name string) (*Shim, error) {
+	switch ext := filepath.Ext(name); strings.ToLower(ext) {
+	case ".exe", ".cmd", ".ps1", ".bat":
+		name = strings.TrimSuffix(name, ext)
+	}
+	for _, extensions := range shimExtensions {
+		path := filepath.Join(scoop.ShimDir(), name+extensions[0])
+		configPath := filepath.Join(scoop.ShimDir(), name+extensions[1])
+		if _, err := os.Stat(configPath); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error checking shim: %w", err)
+		}
+
+		shim, err := ParseShim(configPath)
+		if err != nil {
+			return nil, err
+		}
+		shim.Path = path
+		return shim, nil
+	}
+
+	return nil, nil
+}
+
+// ParseShim reads the target and arguments from a shim file. The format is
+// determined by the file extension. All formats written by
+// [Scoop.CreateShim] are supported.
+func ParseShim(path string) (*Shim, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shim: %w", err)
+	}
+
+	ext := filepath.Ext(path)
+	shim := &Shim{
+		Name:       strings.TrimSuffix(filepath.Base(path), ext),
+		Path:       path,
+		ConfigPath: path,
+	}
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	switch strings.ToLower(ext) {
+	case ".shim":
+		config, err := ParseShimConfig(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing shim '%s': %w", path, err)
+		}
+		shim.Target = config.Path
+		shim.Args = config.Args
+	case ".cmd":
+		// The first line is a comment containing the target.
+		shim.Target = strings.TrimSpace(strings.TrimPrefix(lines[0], "@rem "))
+	case ".ps1":
+		shim.Target = strings.TrimSpace(strings.TrimPrefix(lines[0], "# "))
+	default:
+		// The first line is the shebang, followed by a comment containing
+		// the target.
+		if len(lines) > 1 {
+			shim.Target = strings.TrimSpace(strings.TrimPrefix(lines[1], "# "))
+		}
+	}
+
+	if shim.Target == "" {
+		return nil, fmt.Errorf("shim '%s' doesn't define a target", path)
+	}
+	return shim, nil
+}
+
+// Shims returns all shims found in the shim dir, sorted by name.
+func (scoop *Scoop) Shims() ([]*Shim, error) {
+	entries, err := os.ReadDir(scoop.ShimDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading shim dir: %w", err)
+	}
+
+	// A shim consists of multiple files, so we need to deduplicate.
+	var names []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	shims := make([]*Shim, 0, len(names))
+	for _, name := range names {
+		shim, err := scoop.FindShim(name)
+		if err != nil {
+			return nil, err
+		}
+		// Files that aren't shims, such as an .exe without .shim file.
+		if shim != nil {
+			shims = append(shims, shim)
+		}
+	}
+	return shims, nil
+}
+
+// ShimProvider is an installed app that defines a certain shim.
+type ShimProvider struct {
+	App *InstalledApp
+	Bin Bin
+}
+
+// ShimProviders returns all installed apps that define a shim with the given
+// name, no matter whether the shim currently points to them.
+func (scoop *Scoop) ShimProviders(name string) ([]ShimProvider, error) {
+	apps, err := scoop.InstalledApps()
+	if err != nil {
+		return nil, fmt.Errorf("error getting installed apps: %w", err)
+	}
+
+	var providers []ShimProvider
+	for _, app := range apps {
+		if err := app.LoadDetails(DetailFieldVersion, DetailFieldBin, DetailFieldArchitecture); err != nil {
+			return nil, fmt.Errorf("error loading details of '%s': %w", app.Name, err)
+		}
+		for _, bin := range app.ForArch(app.Architecture).Bin {
+			if strings.EqualFold(bin.ShimName(), name) {
+				providers = append(providers, ShimProvider{App: app, Bin: bin})
+				break
+			}
+		}
+	}
+	return providers, nil
+}
+
+// AlterShim points the shim with the given name to the installation of the
+// given app. This is useful if multiple apps provide the same shim. If the
+// shim has been added by the user, it is replaced.
+func (scoop *Scoop) AlterShim(name, appName string) error {
+	providers, err := scoop.ShimProviders(name)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(providers, func(provider ShimProvider) bool {
+		return strings.EqualFold(provider.App.Name, appName)
+	})
+	if index == -1 {
+		return fmt.Errorf("app '%s' doesn't provide shim '%s'", appName, name)
+	}
+	provider := providers[index]
+
+	registry, err := scoop.LoadShimRegistry()
+	if err != nil {
+		return err
+	}
+
+	// The new shim might be of a different type, so we can't simply
+	// overwrite the existing files.
+	if err := scoop.deleteShimFiles(name); err != nil {
+		return err
+	}
+	if err := scoop.createAppShim(provider.App.App, provider.App.Architecture, provider.Bin); err != nil {
+		return err
+	}
+
+	registry.Set(name, &ShimRegistration{Owner: provider.App.Name})
+	return scoop.SaveShimRegistry(registry)
+}
+
+// AddUserShim creates a shim pointing to an arbitrary file. Existing shims with
+// the same name are replaced. User shims are never touched by installing or
+// resetting apps.
+func (scoop *Scoop) AddUserShim(name, path string, args ...string) error {
+	registry, err := scoop.LoadShimRegistry()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(scoop.ShimDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating shim dir: %w", err)
+	}
+	if err := scoop.deleteShimFiles(name); err != nil {
+		return err
+	}
+	if err := scoop.CreateShim(path, Bin{Name: path, Alias: name, Args: args}); err != nil {
+		return fmt.Errorf("error creating shim: %w", err)
+	}
+
+	registry.Set(name, &ShimRegistration{User: true})
+	return scoop.SaveShimRegistry(registry)
+}
+
+// RemoveShim deletes all files of the shim with the given name. If the shim
+// doesn't exist, [ErrShimNotFound] is returned.
+func (scoop *Scoop) RemoveShim(name string) error {
+	shim, err := scoop.FindShim(name)
+	if err != nil {
+		return err
+	}
+	if shim == nil {
+		return ErrShimNotFound
+	}
+
+	registry, err := scoop.LoadShimRegistry()
+	if err != nil {
+		return err
+	}
+	if err := scoop.deleteShimFiles(name); err != nil {
+		return err
+	}
+
+	registry.Set(name, nil)
+	return scoop.SaveShimRegistry(registry)
+}
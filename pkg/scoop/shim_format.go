@@ -0,0 +1,261 @@
+package scoop
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Bios-Marcel/spoon/internal/windows"
+)
+
+// ShimConfig is the content of a `.shim` file, as read by kiennq/shim.exe.
+type ShimConfig struct {
+	// Path is the executable invoked by the shim.
+	Path string
+	// Args is a command line fragment, which is passed to the executable
+	// before the arguments passed to the shim. It is used as is, so any
+	// arguments containing whitespace need to be quoted.
+	Args string
+}
+
+// ParseShimConfig reads a `.shim` file. Unknown keys are ignored.
+func ParseShimConfig(reader io.Reader) (*ShimConfig, error) {
+	var config ShimConfig
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+
+		// shim.exe expects exactly one space around the equals sign, but
+		// we are more lenient when reading.
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "path":
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				value = value[1 : len(value)-1]
+			}
+			config.Path = value
+		case "args":
+			config.Args = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading shim config: %w", err)
+	}
+	if config.Path == "" {
+		return nil, errors.New("shim config doesn't define a path")
+	}
+	return &config, nil
+}
+
+// Write writes the config in a format understood by shim.exe. Since the format
+// has no escaping mechanism, values containing line breaks are rejected. The
+// same goes for paths containing double quotes, which aren't valid on Windows
+// anyway.
+func (config ShimConfig) Write(writer io.Writer) error {
+	if config.Path == "" {
+		return errors.New("shim config requires a path")
+	}
+	if strings.ContainsAny(config.Path, "\"\r\n") {
+		return fmt.Errorf("invalid shim path: %q", config.Path)
+	}
+	if strings.ContainsAny(config.Args, "\r\n") {
+		return fmt.Errorf("invalid shim arguments: %q", config.Args)
+	}
+
+	if _, err := fmt.Fprintf(writer, "path = \"%s\"\n", config.Path); err != nil {
+		return fmt.Errorf("error writing shim config: %w", err)
+	}
+	if config.Args != "" {
+		if _, err := fmt.Fprintf(writer, "args = %s\n", config.Args); err != nil {
+			return fmt.Errorf("error writing shim config: %w", err)
+		}
+	}
+	return nil
+}
+
+// shimDialect defines how values are embedded into a certain script language.
+type shimDialect struct {
+	// escapePath escapes paths for use inside of the quotes used by the
+	// templates of the dialect.
+	escapePath func(string) string
+	// formatArgs turns a command line fragment, as found in manifests, into
+	// arguments for the dialect.
+	formatArgs func(string) string
+}
+
+var (
+	cmdDialect = shimDialect{
+		escapePath: func(path string) string { return windows.EscapeBatch(path, true) },
+		formatArgs: func(args string) string {
+			split := splitCommandLine(args)
+			for index, arg := range split {
+				split[index] = quoteCmd(arg)
+			}
+			return strings.Join(split, " ")
+		},
+	}
+	shDialect = shimDialect{
+		escapePath: escapeSh,
+		formatArgs: func(args string) string {
+			split := splitCommandLine(args)
+			for index, arg := range split {
+				split[index] = quoteSh(arg)
+			}
+			return strings.Join(split, " ")
+		},
+	}
+	// powerShellDialect passes arguments as they are, since manifests
+	// targetting PowerShell scripts use PowerShell syntax for arguments.
+	powerShellDialect = shimDialect{
+		escapePath: escapePowerShell,
+		formatArgs: func(args string) string { return args },
+	}
+)
+
+// shimTemplate is a script shim written next to other shims of the same
+// name. Each template receives the following arguments:
+//
+//  1. The unescaped target, only used inside of comments
+//  2. The escaped target
+//  3. The arguments to pass to the target
+//  4. The escaped directory containing the target
+type shimTemplate struct {
+	extension string
+	template  string
+	dialect   shimDialect
+}
+
+func (scoop *Scoop) writeShimTemplates(shimName, path string, args []string, templates ...shimTemplate) error {
+	argsJoined := strings.Join(args, " ")
+	for _, template := range templates {
+		content := fmt.Sprintf(template.template,
+			path,
+			template.dialect.escapePath(path),
+			template.dialect.formatArgs(argsJoined),
+			template.dialect.escapePath(filepath.Dir(path)))
+		if err := os.WriteFile(
+			filepath.Join(scoop.ShimDir(), shimName+template.extension),
+			[]byte(content),
+			0o700,
+		); err != nil {
+			return fmt.Errorf("error creating shim: %w", err)
+		}
+	}
+	return nil
+}
+
+// quoteCmd turns the value into a single argument in a batch file. The
+// argument is quoted the way Windows programs parse their command line and
+// then escaped for cmd. Values that don't require quoting are returned
+// unquoted, to keep scripts readable.
+func quoteCmd(value string) string {
+	if value != "" && strings.Trim(value,
+		"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=.,:/\\@%") == "" {
+		return windows.EscapeBatch(value, false)
+	}
+
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	var backslashes int
+	for _, char := range value {
+		switch char {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// Backslashes preceding a quote need to be escaped as well.
+			quoted.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			quoted.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		quoted.WriteRune(char)
+		backslashes = 0
+	}
+	quoted.WriteString(strings.Repeat(`\`, backslashes*2))
+	quoted.WriteByte('"')
+
+	return windows.EscapeBatch(quoted.String(), false)
+}
+
+// escapeSh escapes a string for use inside of double quotes in a POSIX shell.
+func escapeSh(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"`", "\\`",
+	).Replace(value)
+}
+
+// quoteSh turns the value into a single POSIX shell word. Values that don't
+// require quoting are returned as is, to keep scripts readable.
+func quoteSh(value string) string {
+	if value != "" && strings.Trim(value,
+		"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=.,:/@%") == "" {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// escapePowerShell escapes a string for use inside of single quotes in
+// PowerShell, which don't allow any kind of expansion.
+func escapePowerShell(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// splitCommandLine splits a command line the same way Windows programs do, see
+// https://learn.microsoft.com/en-us/cpp/c-language/parsing-c-command-line-arguments.
+func splitCommandLine(commandLine string) []string {
+	var (
+		args        []string
+		current     strings.Builder
+		inArg       bool
+		quoted      bool
+		backslashes int
+	)
+	for _, char := range commandLine {
+		switch {
+		case char == '\\':
+			backslashes++
+			inArg = true
+			continue
+		case char == '"':
+			// Backslashes only escape if followed by a quote.
+			current.WriteString(strings.Repeat(`\`, backslashes/2))
+			if backslashes%2 == 1 {
+				current.WriteRune('"')
+			} else {
+				quoted = !quoted
+			}
+			backslashes = 0
+			inArg = true
+			continue
+		}
+
+		current.WriteString(strings.Repeat(`\`, backslashes))
+		backslashes = 0
+		if !quoted && (char == ' ' || char == '\t') {
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+			continue
+		}
+		current.WriteRune(char)
+		inArg = true
+	}
+
+	current.WriteString(strings.Repeat(`\`, backslashes))
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
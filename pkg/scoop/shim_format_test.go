@@ -0,0 +1,143 @@
+package scoop_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ShimConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("roundtrip", func(t *testing.T) {
+		t.Parallel()
+
+		config := scoop.ShimConfig{
+			Path: `C:\Program Files\100%\app.exe`,
+			Args: `-c "C:\Program Files\app.conf" --name=$value`,
+		}
+		var buffer bytes.Buffer
+		require.NoError(t, config.Write(&buffer))
+		require.Equal(t,
+			"path = \"C:\\Program Files\\100%\\app.exe\"\n"+
+				"args = -c \"C:\\Program Files\\app.conf\" --name=$value\n",
+			buffer.String())
+
+		parsed, err := scoop.ParseShimConfig(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, config, *parsed)
+	})
+	t.Run("no args", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		require.NoError(t, scoop.ShimConfig{Path: `C:\app.exe`}.Write(&buffer))
+		require.Equal(t, "path = \"C:\\app.exe\"\n", buffer.String())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		require.Error(t, scoop.ShimConfig{}.Write(&buffer))
+		require.Error(t, scoop.ShimConfig{Path: "C:\\app.exe\nargs = evil"}.Write(&buffer))
+		require.Error(t, scoop.ShimConfig{Path: `C:\app.exe`, Args: "a\r\nb"}.Write(&buffer))
+		require.Empty(t, buffer.String())
+
+		_, err := scoop.ParseShimConfig(strings.NewReader("args = a\n"))
+		require.Error(t, err)
+	})
+	t.Run("unquoted and CRLF", func(t *testing.T) {
+		t.Parallel()
+
+		config, err := scoop.ParseShimConfig(strings.NewReader("path = C:\\app.exe\r\nargs = -v\r\n"))
+		require.NoError(t, err)
+		require.Equal(t, scoop.ShimConfig{Path: `C:\app.exe`, Args: "-v"}, *config)
+	})
+}
+
+func Test_SplitCommandLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		commandLine string
+		expected    []string
+	}{
+		{``, nil},
+		{`a b  c`, []string{"a", "b", "c"}},
+		{`-c "C:\Program Files\a.conf"`, []string{"-c", `C:\Program Files\a.conf`}},
+		{`--name="a b"c`, []string{"--name=a bc"}},
+		{`""`, []string{""}},
+		{`a\\b \"quoted\"`, []string{`a\\b`, `"quoted"`}},
+		{`"trailing\\" x`, []string{`trailing\`, "x"}},
+		{`end\`, []string{`end\`}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, scoop.SplitCommandLine(test.commandLine), test.commandLine)
+	}
+}
+
+func Test_ShDialect(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t,
+		`-c 'C:\Program Files\a.conf' '$HOME' 'it'\''s' ''`,
+		scoop.FormatShArgs(`-c "C:\Program Files\a.conf" $HOME "it's" ""`))
+	require.Equal(t, `C:\\a\\\$b\"`, scoop.EscapeShPath(`C:\a\$b"`))
+	require.Equal(t, `100%%`, scoop.FormatCmdArgs(`100%`))
+	require.Equal(t, `it''s`, scoop.EscapePowerShellPath(`it's`))
+}
+
+// unescapeCmd removes the escaping cmd removes when running a batch file
+// line, which is doubled percent signs and carets outside of quotes.
+func unescapeCmd(line string) string {
+	line = strings.ReplaceAll(line, "%%", "%")
+	var (
+		result   strings.Builder
+		inQuotes bool
+		escaped  bool
+	)
+	for _, char := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '"':
+			inQuotes = !inQuotes
+		case char == '^' && !inQuotes:
+			escaped = true
+			continue
+		}
+		result.WriteRune(char)
+	}
+	return result.String()
+}
+
+func Test_CmdDialect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     string
+		expected string
+	}{
+		{`-v --name=a`, `-v --name=a`},
+		{`100%`, `100%%`},
+		{`-c "C:\Program Files\a.conf"`, `-c "C:\Program Files\a.conf"`},
+		{`a&b`, `"a&b"`},
+		{`"x)" (y)`, `"x)" "(y)"`},
+		{`a|b "c>d" ^`, `"a|b" "c>d" "^"`},
+		{`"it\"s&calc" ""`, `"it\"s^&calc" ""`},
+		{`"trailing dir\\" "100%"`, `"trailing dir\\" 100%%`},
+	}
+	for _, test := range tests {
+		formatted := scoop.FormatCmdArgs(test.args)
+		require.Equal(t, test.expected, formatted, test.args)
+		// After cmd is done with the line, the target has to receive the
+		// same arguments as defined in the manifest.
+		require.Equal(t,
+			scoop.SplitCommandLine(test.args),
+			scoop.SplitCommandLine(unescapeCmd(formatted)),
+			test.args)
+	}
+}
@@ -0,0 +1,82 @@
+package scoop
+
+import (
+	stdJson "encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ShimRegistration is spoon specific information about a shim, which can't be
+// derived from the shim files themselves.
+type ShimRegistration struct {
+	// Owner is the app that created the shim. It is empty for shims that
+	// have been added by the user.
+	Owner string `json:"owner,omitempty"`
+	// User indicates that the shim has been manually added by the user. Such
+	// shims aren't touched when installing or resetting apps.
+	User bool `json:"user,omitempty"`
+}
+
+// ShimRegistry maps lowercased shim names to their registration. Shims created
+// before spoon started registering them are missing.
+type ShimRegistry map[string]*ShimRegistration
+
+// Get returns the registration for the given shim name or nil.
+func (registry ShimRegistry) Get(name string) *ShimRegistration {
+	return registry[strings.ToLower(name)]
+}
+
+// Set registers the shim. Passing nil removes the registration.
+func (registry ShimRegistry) Set(name string, registration *ShimRegistration) {
+	if registration == nil {
+		delete(registry, strings.ToLower(name))
+		return
+	}
+	registry[strings.ToLower(name)] = registration
+}
+
+// SpoonDir contains all data that spoon stores in addition to what scoop
+// stores. Scoop itself ignores this directory.
+func (scoop *Scoop) SpoonDir() string {
+	return filepath.Join(scoop.scoopRoot, "spoon")
+}
+
+func (scoop *Scoop) shimRegistryPath() string {
+	return filepath.Join(scoop.SpoonDir(), "shims.json")
+}
+
+// LoadShimRegistry reads the shim registry. If no registry has been written
+// yet, an empty registry is returned.
+func (scoop *Scoop) LoadShimRegistry() (ShimRegistry, error) {
+	registry := make(ShimRegistry)
+	data, err := os.ReadFile(scoop.shimRegistryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("error reading shim registry: %w", err)
+	}
+
+	if err := stdJson.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("error parsing shim registry: %w", err)
+	}
+	return registry, nil
+}
+
+// SaveShimRegistry persists the given registry, replacing the existing one.
+func (scoop *Scoop) SaveShimRegistry(registry ShimRegistry) error {
+	if err := os.MkdirAll(scoop.SpoonDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating spoon dir: %w", err)
+	}
+
+	data, err := stdJson.MarshalIndent(registry, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error encoding shim registry: %w", err)
+	}
+	if err := os.WriteFile(scoop.shimRegistryPath(), data, 0o600); err != nil {
+		return fmt.Errorf("error writing shim registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package scoop_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FindShim(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	require.NoError(t, os.MkdirAll(customScoop.ShimDir(), os.ModePerm))
+
+	exeTarget := filepath.Join(customScoop.AppDir(), "app", "current", "bin", "app.exe")
+	require.NoError(t, customScoop.CreateShim(exeTarget, scoop.Bin{
+		Name: "app.exe",
+		Args: []string{"--flag"},
+	}))
+	cmdTarget := filepath.Join(customScoop.AppDir(), "app", "current", "tool.cmd")
+	require.NoError(t, customScoop.CreateShim(cmdTarget, scoop.Bin{
+		Name:  "tool.cmd",
+		Alias: "tool",
+	}))
+
+	shim, err := customScoop.FindShim("app.exe")
+	require.NoError(t, err)
+	require.NotNil(t, shim)
+	require.Equal(t, "app", shim.Name)
+	require.Equal(t, filepath.Join(customScoop.ShimDir(), "app.exe"), shim.Path)
+	require.Equal(t, filepath.Join(customScoop.ShimDir(), "app.shim"), shim.ConfigPath)
+	require.Equal(t, exeTarget, shim.Target)
+	require.Equal(t, "--flag", shim.Args)
+
+	shim, err = customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.NotNil(t, shim)
+	require.Equal(t, filepath.Join(customScoop.ShimDir(), "tool.cmd"), shim.Path)
+	require.Equal(t, cmdTarget, shim.Target)
+
+	// The bash variant of a cmd shim.
+	shim, err = scoop.ParseShim(filepath.Join(customScoop.ShimDir(), "tool"))
+	require.NoError(t, err)
+	require.Equal(t, cmdTarget, shim.Target)
+
+	// Dotted names are only stripped of executable extensions.
+	pythonTarget := filepath.Join(customScoop.AppDir(), "python312", "current", "python.exe")
+	require.NoError(t, customScoop.CreateShim(pythonTarget, scoop.Bin{
+		Name:  "python.exe",
+		Alias: "python3.12",
+	}))
+	for _, name := range []string{"python3.12", "python3.12.exe", "python3.12.EXE"} {
+		shim, err = customScoop.FindShim(name)
+		require.NoError(t, err)
+		require.NotNil(t, shim, name)
+		require.Equal(t, pythonTarget, shim.Target)
+	}
+	shim, err = customScoop.FindShim("python3")
+	require.NoError(t, err)
+	require.Nil(t, shim)
+
+	shim, err = customScoop.FindShim("missing")
+	require.NoError(t, err)
+	require.Nil(t, shim)
+}
+
+func Test_FindOwningApp(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"),
+		[]byte(`{"version": "1.2.3"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+		[]byte(`{"bucket": "main"}`), 0o600))
+
+	appName, version, err := customScoop.FindOwningApp(filepath.Join(currentDir, "bin", "app.exe"))
+	require.NoError(t, err)
+	require.Equal(t, "app", appName)
+	require.Equal(t, "1.2.3", version)
+
+	appName, version, err = customScoop.FindOwningApp(
+		filepath.Join(customScoop.AppDir(), "app", "1.0.0-Beta", "app.exe"))
+	require.NoError(t, err)
+	require.Equal(t, "app", appName)
+	require.Equal(t, "1.0.0-Beta", version)
+
+	appName, version, err = customScoop.FindOwningApp(filepath.Join(t.TempDir(), "app.exe"))
+	require.NoError(t, err)
+	require.Empty(t, appName)
+	require.Empty(t, version)
+}
+
+func Test_UserShims(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	target := filepath.Join(t.TempDir(), "tool.cmd")
+	require.NoError(t, customScoop.AddUserShim("mytool", target, "--verbose"))
+
+	shims, err := customScoop.Shims()
+	require.NoError(t, err)
+	require.Len(t, shims, 1)
+	require.Equal(t, "mytool", shims[0].Name)
+	require.Equal(t, target, shims[0].Target)
+
+	registry, err := customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.NotNil(t, registry.Get("MyTool"))
+	require.True(t, registry.Get("mytool").User)
+
+	require.NoError(t, customScoop.RemoveShim("mytool"))
+	require.ErrorIs(t, customScoop.RemoveShim("mytool"), scoop.ErrShimNotFound)
+
+	shims, err = customScoop.Shims()
+	require.NoError(t, err)
+	require.Empty(t, shims)
+
+	registry, err = customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.Nil(t, registry.Get("mytool"))
+}
+
+func Test_AlterShim(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	for _, appName := range []string{"a", "b"} {
+		currentDir := filepath.Join(customScoop.AppDir(), appName, "current")
+		require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"),
+			[]byte(`{"version": "1.0.0", "bin": "tool.cmd"}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+			[]byte(`{"bucket": "main"}`), 0o600))
+	}
+	require.NoError(t, customScoop.AddUserShim("tool", filepath.Join(t.TempDir(), "tool.cmd")))
+
+	providers, err := customScoop.ShimProviders("tool")
+	require.NoError(t, err)
+	require.Len(t, providers, 2)
+
+	require.NoError(t, customScoop.AlterShim("tool", "b"))
+	shim, err := customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(customScoop.AppDir(), "b", "current", "tool.cmd"), shim.Target)
+
+	// The user shim has been replaced, so it isn't protected anymore.
+	registry, err := customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.Equal(t, &scoop.ShimRegistration{Owner: "b"}, registry.Get("tool"))
+
+	require.Error(t, customScoop.AlterShim("tool", "c"))
+}
+
+func Test_AlterShimSubstitutesArgs(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	currentDir := filepath.Join(customScoop.AppDir(), "app", "current")
+	require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"), []byte(`{
+		"version": "1.0.0",
+		"bin": [["bin\\tool.exe", "tool", "--config", "$persist_dir\\config", "--home", "$dir"]]
+	}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+		[]byte(`{"bucket": "main"}`), 0o600))
+
+	require.NoError(t, customScoop.AlterShim("tool", "app"))
+	shim, err := customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(currentDir, "bin", "tool.exe"), shim.Target)
+	require.Equal(t, "--config "+customScoop.AppPersistDir("app")+`\config --home `+currentDir, shim.Args)
+}
+
+func Test_CreateScriptShims(t *testing.T) {
+	t.Parallel()
+
+	// Contains characters that need escaping in all script languages.
+	dir := filepath.Join(t.TempDir(), "it's 100% $cheap")
+
+	type shimFile struct {
+		name    string
+		content string
+	}
+	tests := []struct {
+		name  string
+		bin   scoop.Bin
+		files []shimFile
+	}{
+		{
+			name: "ps1",
+			bin:  scoop.Bin{Name: "tool.ps1", Args: []string{"-Verbose"}},
+			files: []shimFile{
+				{"tool.ps1", "# " + filepath.Join(dir, "tool.ps1") + "\n" +
+					"$path = '" + strings.ReplaceAll(filepath.Join(dir, "tool.ps1"), "'", "''") + "'\n" +
+					"if ($MyInvocation.ExpectingInput) { $input | & $path -Verbose @args } else { & $path -Verbose @args }\n" +
+					"exit $LASTEXITCODE\n"},
+				{"tool.cmd", "@rem " + filepath.Join(dir, "tool.ps1") + "\n" +
+					"@echo off\n" +
+					"where /q pwsh.exe\n" +
+					"if %errorlevel% equ 0 (\n" +
+					`    pwsh -noprofile -ex unrestricted -file "` + strings.ReplaceAll(filepath.Join(dir, "tool.ps1"), "%", "%%") + `" -Verbose %*` + "\n" +
+					") else (\n" +
+					`    powershell -noprofile -ex unrestricted -file "` + strings.ReplaceAll(filepath.Join(dir, "tool.ps1"), "%", "%%") + `" -Verbose %*` + "\n" +
+					")\n"},
+				{"tool", "#!/bin/sh\n" +
+					"# " + filepath.Join(dir, "tool.ps1") + "\n" +
+					"if command -v pwsh.exe > /dev/null 2>&1; then\n" +
+					`    pwsh.exe -noprofile -ex unrestricted -file "` + strings.ReplaceAll(filepath.Join(dir, "tool.ps1"), "$", `\$`) + `" -Verbose "$@"` + "\n" +
+					"else\n" +
+					`    powershell.exe -noprofile -ex unrestricted -file "` + strings.ReplaceAll(filepath.Join(dir, "tool.ps1"), "$", `\$`) + `" -Verbose "$@"` + "\n" +
+					"fi\n"},
+			},
+		},
+		{
+			name: "jar",
+			bin:  scoop.Bin{Name: "tool.jar", Alias: "jartool"},
+			files: []shimFile{
+				{"jartool.cmd", "@rem " + filepath.Join(dir, "tool.jar") + "\n" +
+					`@pushd "` + strings.ReplaceAll(dir, "%", "%%") + `"` + "\n" +
+					`@java -jar "` + strings.ReplaceAll(filepath.Join(dir, "tool.jar"), "%", "%%") + `"  %*` + "\n" +
+					"@popd\n"},
+				{"jartool", "#!/bin/sh\n" +
+					"# " + filepath.Join(dir, "tool.jar") + "\n" +
+					"if [ \"$WSL_INTEROP\" ]\n" +
+					"then\n" +
+					`  cd "$(wslpath -u "` + strings.ReplaceAll(dir, "$", `\$`) + `")"` + "\n" +
+					"else\n" +
+					`  cd "$(cygpath -u "` + strings.ReplaceAll(dir, "$", `\$`) + `")"` + "\n" +
+					"fi\n" +
+					`java.exe -jar "` + strings.ReplaceAll(filepath.Join(dir, "tool.jar"), "$", `\$`) + `"  "$@"` + "\n"},
+			},
+		},
+		{
+			name: "py",
+			bin:  scoop.Bin{Name: "tool.py", Args: []string{"-c", `"C:\My Config\100%.conf"`}},
+			files: []shimFile{
+				{"tool.cmd", "@rem " + filepath.Join(dir, "tool.py") + "\n" +
+					`@python "` + strings.ReplaceAll(filepath.Join(dir, "tool.py"), "%", "%%") + `" -c "C:\My Config\100%%.conf" %*` + "\n"},
+				{"tool", "#!/bin/sh\n" +
+					"# " + filepath.Join(dir, "tool.py") + "\n" +
+					`python.exe "` + strings.ReplaceAll(filepath.Join(dir, "tool.py"), "$", `\$`) + `" -c 'C:\My Config\100%.conf' "$@"` + "\n"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			customScoop := scoop.NewCustomScoop(t.TempDir())
+			require.NoError(t, os.MkdirAll(customScoop.ShimDir(), os.ModePerm))
+			target := filepath.Join(dir, test.bin.Name)
+			require.NoError(t, customScoop.CreateShim(target, test.bin))
+
+			entries, err := os.ReadDir(customScoop.ShimDir())
+			require.NoError(t, err)
+			require.Len(t, entries, len(test.files))
+
+			for _, file := range test.files {
+				content, err := os.ReadFile(filepath.Join(customScoop.ShimDir(), file.name))
+				require.NoError(t, err)
+				require.Equal(t, file.content, string(content), file.name)
+			}
+
+			shim, err := customScoop.FindShim(test.bin.ShimName())
+			require.NoError(t, err)
+			require.Equal(t, target, shim.Target)
+		})
+	}
+}
+
+func Test_RemoveShimsOwnership(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	require.NoError(t, os.MkdirAll(customScoop.ShimDir(), os.ModePerm))
+	for _, appName := range []string{"a", "b"} {
+		currentDir := filepath.Join(customScoop.AppDir(), appName, "current")
+		require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"),
+			[]byte(`{"version": "1.0.0", "bin": "tool.cmd"}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+			[]byte(`{"bucket": "main"}`), 0o600))
+	}
+	bin := scoop.Bin{Name: "tool.cmd"}
+
+	// Shims without registration are attributed to the app containing the
+	// target.
+	require.NoError(t, customScoop.CreateShim(
+		filepath.Join(customScoop.AppDir(), "a", "current", "tool.cmd"), bin))
+
+	// Not owned by b, so nothing happens.
+	require.NoError(t, customScoop.RemoveShims("b", bin))
+	shim, err := customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(customScoop.AppDir(), "a", "current", "tool.cmd"), shim.Target)
+
+	// Updates keep the shims the new version provides.
+	require.NoError(t, scoop.RemoveShimsKeeping(customScoop, "a", []scoop.Bin{bin}, bin))
+	shim, err = customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(customScoop.AppDir(), "a", "current", "tool.cmd"), shim.Target)
+
+	// Uninstalling a should point the shim to b.
+	require.NoError(t, os.RemoveAll(filepath.Join(customScoop.AppDir(), "a")))
+	require.NoError(t, customScoop.RemoveShims("a", bin))
+	shim, err = customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(customScoop.AppDir(), "b", "current", "tool.cmd"), shim.Target)
+
+	registry, err := customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.Equal(t, "b", registry.Get("tool").Owner)
+
+	// No other provider left, so the shim is deleted.
+	require.NoError(t, os.RemoveAll(filepath.Join(customScoop.AppDir(), "b")))
+	require.NoError(t, customScoop.RemoveShims("b", bin))
+	shim, err = customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Nil(t, shim)
+
+	registry, err = customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	require.Nil(t, registry.Get("tool"))
+}
+
+func Test_RemoveShimsHandsOverSubstitutedShim(t *testing.T) {
+	t.Parallel()
+
+	customScoop := scoop.NewCustomScoop(t.TempDir())
+	for _, appName := range []string{"a", "b"} {
+		currentDir := filepath.Join(customScoop.AppDir(), appName, "current")
+		require.NoError(t, os.MkdirAll(currentDir, os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "manifest.json"),
+			[]byte(`{"version": "1.0.0", "bin": [["bin\\tool.exe", "tool", "--home", "$dir"]]}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(currentDir, "install.json"),
+			[]byte(`{"bucket": "main"}`), 0o600))
+	}
+	bin := scoop.Bin{Name: `bin\tool.exe`, Alias: "tool", Args: []string{"--home", "$dir"}}
+	require.NoError(t, customScoop.CreateShim(
+		filepath.Join(customScoop.AppDir(), "a", "current", "bin", "tool.exe"), bin))
+	registry, err := customScoop.LoadShimRegistry()
+	require.NoError(t, err)
+	registry.Set("tool", &scoop.ShimRegistration{Owner: "a"})
+	require.NoError(t, customScoop.SaveShimRegistry(registry))
+
+	require.NoError(t, os.RemoveAll(filepath.Join(customScoop.AppDir(), "a")))
+	require.NoError(t, customScoop.RemoveShims("a", bin))
+
+	// The shim looks just like the one created on installation of b.
+	currentDir := filepath.Join(customScoop.AppDir(), "b", "current")
+	shim, err := customScoop.FindShim("tool")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(currentDir, "bin", "tool.exe"), shim.Target)
+	require.Equal(t, "--home "+currentDir, shim.Args)
+}
@@ -0,0 +1,150 @@
+package scoop
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// manifestVariables are the values of the variables scoop supports in
+// manifest fields, such as scripts, installer args, shim args and env_set.
+// Names are lowercase and don't include the `$`.
+type manifestVariables map[string]string
+
+// manifestVariables returns the variables for the given app. dir is the
+// directory the app is referenced by in the current context. During
+// installation this is the version dir, while links, such as shims and env
+// vars, use the `current` dir.
+func (scoop *Scoop) manifestVariables(app *App, arch ArchitectureKey, dir, versionDir string) manifestVariables {
+	return manifestVariables{
+		"app":          app.Name,
+		"version":      app.Version,
+		"architecture": string(arch),
+		"dir":          dir,
+		"original_dir": versionDir,
+		"persist_dir":  scoop.AppPersistDir(app.Name),
+		"scoopdir":     scoop.scoopRoot,
+		"bucketsdir":   scoop.BucketDir(),
+		"cachedir":     scoop.CacheDir(),
+		// We don't support global installs.
+		"global": "$false",
+	}
+}
+
+// with returns a copy containing the additional variable.
+func (variables manifestVariables) with(name, value string) manifestVariables {
+	copied := maps.Clone(variables)
+	copied[strings.ToLower(name)] = value
+	return copied
+}
+
+// substitute replaces all known variables in the value. Variables are
+// tokenized, so `$dir` neither matches `$directory` nor `$persist_dir`. Names
+// are case insensitive, as in PowerShell, and can also be written as
+// `${name}`. `$env:NAME` is replaced with the environment variable of the
+// current process, or nothing if unset, as PowerShell does. Unknown variables
+// are kept as they are.
+func (variables manifestVariables) substitute(value string) string {
+	return variables.replace(value, true)
+}
+
+// substituteManifest is like [manifestVariables.substitute], but keeps
+// `$env:NAME` as it is. This is meant for output, which mustn't contain the
+// environment of the current process, as it might contain secrets.
+func (variables manifestVariables) substituteManifest(value string) string {
+	return variables.replace(value, false)
+}
+
+func (variables manifestVariables) replace(value string, expandEnv bool) string {
+	if !strings.Contains(value, "$") {
+		return value
+	}
+
+	var result strings.Builder
+	for index := 0; index < len(value); {
+		if value[index] != '$' {
+			next := strings.IndexByte(value[index:], '$')
+			if next == -1 {
+				result.WriteString(value[index:])
+				break
+			}
+			result.WriteString(value[index : index+next])
+			index += next
+			continue
+		}
+
+		name, length := readVariableName(value[index+1:])
+		token := value[index : index+1+length]
+		index += 1 + length
+
+		if envName, ok := cutPrefixFold(name, "env:"); ok && envName != "" {
+			if expandEnv {
+				result.WriteString(os.Getenv(envName))
+			} else {
+				result.WriteString(token)
+			}
+		} else if replacement, ok := variables[strings.ToLower(name)]; ok {
+			result.WriteString(replacement)
+		} else {
+			result.WriteString(token)
+		}
+	}
+	return result.String()
+}
+
+// readVariableName reads the name following a `$`. The returned length is
+// the amount of bytes consumed, including braces.
+func readVariableName(value string) (string, int) {
+	if strings.HasPrefix(value, "{") {
+		end := strings.IndexByte(value, '}')
+		if end == -1 {
+			return "", 0
+		}
+		return value[1:end], end + 1
+	}
+
+	length := 0
+	for length < len(value) && isVariableChar(value[length]) {
+		length++
+	}
+	// Env variables are scoped, such as $env:PATH.
+	if strings.EqualFold(value[:length], "env") && length < len(value) && value[length] == ':' {
+		length++
+		for length < len(value) && isVariableChar(value[length]) {
+			length++
+		}
+	}
+	return value[:length], length
+}
+
+func isVariableChar(char byte) bool {
+	return char == '_' ||
+		(char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9')
+}
+
+func cutPrefixFold(value, prefix string) (string, bool) {
+	if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+		return value[len(prefix):], true
+	}
+	return value, false
+}
+
+// powershellPrelude returns lines defining all variables, so that scripts can
+// use them natively, just like in scoop. This is safer than substituting
+// them, as values don't need to be quoted in the script.
+func (variables manifestVariables) powershellPrelude() []string {
+	lines := make([]string, 0, len(variables))
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		value := variables[name]
+		// Booleans are PowerShell values, not strings.
+		if value == "$false" || value == "$true" {
+			lines = append(lines, "$"+name+" = "+value)
+			continue
+		}
+		lines = append(lines, "$"+name+" = '"+strings.ReplaceAll(value, "'", "''")+"'")
+	}
+	return lines
+}
@@ -0,0 +1,108 @@
+package scoop_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Bios-Marcel/spoon/pkg/scoop"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SubstituteVariables(t *testing.T) {
+	t.Setenv("SPOON_TEST_VAR", `C:\Users\me`)
+
+	variables := map[string]string{
+		"dir":          `C:\scoop\apps\app\current`,
+		"original_dir": `C:\scoop\apps\app\1.0.0`,
+		"persist_dir":  `C:\scoop\persist\app`,
+		"version":      "1.0.0",
+		"global":       "$false",
+	}
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"no variables", `C:\path`, `C:\path`},
+		{"simple", `$dir\bin`, `C:\scoop\apps\app\current\bin`},
+		{"prefix of other variable", `$persist_dir\data`, `C:\scoop\persist\app\data`},
+		{"original dir", `$original_dir`, `C:\scoop\apps\app\1.0.0`},
+		{"unknown variable with known prefix", `$directory\$dir`, `$directory\C:\scoop\apps\app\current`},
+		{"underscore suffix", `$dir_old`, `$dir_old`},
+		{"case insensitive", `$DIR`, `C:\scoop\apps\app\current`},
+		{"braces", `${dir}s`, `C:\scoop\apps\app\currents`},
+		{"unclosed braces", `${dir`, `${dir`},
+		{"multiple", `--config=$persist_dir\config --version=$version`, `--config=C:\scoop\persist\app\config --version=1.0.0`},
+		{"adjacent", `$version$version`, `1.0.01.0.0`},
+		{"env", `$env:SPOON_TEST_VAR\bin`, `C:\Users\me\bin`},
+		{"env braces", `${env:SPOON_TEST_VAR}`, `C:\Users\me`},
+		{"env unset", `[$env:SPOON_TEST_UNSET]`, `[]`},
+		{"env without name", `$env:`, `$env:`},
+		{"lone dollar", `costs 5$`, `costs 5$`},
+		{"dollar followed by separator", `$ $\`, `$ $\`},
+		{"values aren't substituted again", `$global`, `$false`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Equal(t, testCase.expected, scoop.SubstituteVariables(testCase.value, variables))
+		})
+	}
+}
+
+func Test_SubstituteManifestVariables(t *testing.T) {
+	t.Setenv("SPOON_TEST_VAR", `C:\Users\me`)
+
+	variables := map[string]string{"dir": `C:\scoop\apps\app\current`}
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"manifest variable", `$dir\bin`, `C:\scoop\apps\app\current\bin`},
+		{"env", `$env:SPOON_TEST_VAR\bin`, `$env:SPOON_TEST_VAR\bin`},
+		{"env braces", `${env:SPOON_TEST_VAR}`, `${env:SPOON_TEST_VAR}`},
+		{"env unset", `[$env:SPOON_TEST_UNSET]`, `[$env:SPOON_TEST_UNSET]`},
+		{"mixed", `$dir;$ENV:SPOON_TEST_VAR`, `C:\scoop\apps\app\current;$ENV:SPOON_TEST_VAR`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Equal(t, testCase.expected, scoop.SubstituteManifestVariables(testCase.value, variables))
+		})
+	}
+}
+
+func Test_ManifestVariables(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	customScoop := scoop.NewCustomScoop(root)
+	app := &scoop.App{Name: "app", Version: "1.0.0"}
+	variables := scoop.ManifestVariables(customScoop, app, scoop.ArchitectureKey64Bit, "current", "1.0.0")
+
+	require.Equal(t, map[string]string{
+		"app":          "app",
+		"version":      "1.0.0",
+		"architecture": "64bit",
+		"dir":          "current",
+		"original_dir": "1.0.0",
+		"persist_dir":  filepath.Join(root, "persist", "app"),
+		"scoopdir":     root,
+		"bucketsdir":   customScoop.BucketDir(),
+		"cachedir":     customScoop.CacheDir(),
+		"global":       "$false",
+	}, variables)
+}
+
+func Test_PowershellPrelude(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{
+		`$dir = 'C:\it''s'`,
+		`$global = $false`,
+	}, scoop.PowershellPrelude(map[string]string{
+		"global": "$false",
+		"dir":    `C:\it's`,
+	}))
+}